@@ -1,35 +1,120 @@
 package main
 
 import (
-	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"ai-notetaking-be/internal/config"
+	healthcontroller "ai-notetaking-be/internal/controller/health"
+	"ai-notetaking-be/internal/metrics"
 	noterepository "ai-notetaking-be/internal/repository/note"
 	consumerservice "ai-notetaking-be/internal/service/consumer"
-	"ai-notetaking-be/pkg/database"
+	healthservice "ai-notetaking-be/internal/service/health"
+	"ai-notetaking-be/internal/wiring"
+	"ai-notetaking-be/pkg/lifecycle"
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/rabbitmq"
+	"ai-notetaking-be/pkg/tracing"
 	"context"
-	"os"
+	"log"
+	"log/slog"
 
-	"github.com/joho/godotenv"
+	"github.com/gofiber/fiber/v2"
 )
 
 func main() {
-	godotenv.Load()
-	ctx := context.Background()
-
-	db := database.ConnectDB(os.Getenv("DB_CONNECTION_STRING"))
-
-	noteRepository := noterepository.NewNoteRepository(db)
-	embeddingRepository := embeddingrepository.NewEmbeddingRepository(db)
-	consumer := consumerservice.NewEmbedNoteConsumerService(
-		os.Getenv("RABBITMQ_CONNECTION_STRING"),
-		"embed-note-content",
-		db,
-		os.Getenv("EMBEDDING_SERVER_BASE_URL"),
-		os.Getenv("EMBEDDING_MODEL_NAME"),
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.RabbitMq.ConnectionString == "" {
+		log.Fatal("rabbitmq.connection_string (RABBITMQ_CONNECTION_STRING) is required")
+	}
+	logging.Setup(cfg.Log.Format, cfg.Log.Level)
+	lc := lifecycle.NewManager(cfg.ShutdownTimeout)
+	ctx := lc.Context()
+	shutdownTracing, err := tracing.Setup(
+		ctx,
+		"ai-notetaking-embednoteconsumer",
+		cfg.Tracing.Exporter,
+		cfg.Tracing.Endpoint,
+		cfg.Tracing.SampleRatio,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	appMetrics := metrics.New()
+	opsApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	opsApp.Get("/metrics", appMetrics.Handler())
+
+	db, err := wiring.ConnectDatabase(ctx, cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	embeddingRepository := wiring.NewEmbeddingRepository(db, cfg.Database.VectorSearch)
+	events := wiring.NewEventConsumer(cfg, db, appMetrics, noterepository.NewNoteRepository(db), embeddingRepository)
+	consumer := consumerservice.NewConsumerGroup(
+		consumerservice.NewEmbedNoteConsumerService(
+			cfg.RabbitMq.ConnectionString,
+			cfg.Queue.Name,
+			events.Handler,
+			events.Limiter,
+			cfg.Consumer.Prefetch,
+		),
+		consumerservice.NewEmbedNoteConsumerService(
+			cfg.RabbitMq.ConnectionString,
+			cfg.Queue.BulkName(),
+			events.Handler,
+			events.BulkLimiter,
+			events.BulkLimiter.Limit(),
+		),
+	)
+
+	brokerConn := rabbitmq.NewConnection(cfg.RabbitMq.ConnectionString, nil)
+	healthCheck := func(name string, probe healthservice.Probe) *healthservice.Check {
+		return healthservice.NewCheck(name, probe, cfg.Health.ProbeInterval, cfg.Health.ProbeTimeout)
+	}
+	healthService := healthservice.NewHealthService(
+		[]*healthservice.Check{
+			healthCheck("database", healthservice.DatabaseProbe(db)),
+			healthCheck("pgvector", healthservice.VectorExtensionProbe(db)),
+			healthCheck("rabbitmq", healthservice.RabbitMqProbe(brokerConn)),
+			healthCheck("gemini", healthservice.EmbeddingProviderProbe(events.GeminiProvider)),
+		},
+		healthservice.NewRabbitMqQueueInspector(brokerConn),
+		[]string{cfg.Queue.Name, cfg.Queue.BulkName()},
 		embeddingRepository,
-		noteRepository,
+		events.EmbeddingProvider.Model(),
 	)
-	err := consumer.Consume(ctx)
+	healthcontroller.AssignHealthRoutes(opsApp, healthcontroller.NewHealthController(healthService))
+
+	lc.OnShutdown("ops server", opsApp.ShutdownWithContext)
+	lc.OnShutdown("embedding consumer", consumer.Shutdown)
+	events.OnShutdown(lc)
+	lc.OnShutdown("rabbitmq health connection", func(ctx context.Context) error {
+		return brokerConn.Close()
+	})
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		db.Close()
+		return nil
+	})
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	go events.WebhookDispatcher.Run(ctx)
+	go func() {
+		err := opsApp.Listen(cfg.Metrics.Addr)
+		if err != nil {
+			slog.Error("Ops server stopped", "error", err)
+		}
+	}()
+	go func() {
+		err := consumer.Consume(ctx)
+		if err != nil {
+			slog.Error("Consumer stopped", "error", err)
+		}
+		lc.Stop()
+	}()
+
+	err = lc.Wait()
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 }
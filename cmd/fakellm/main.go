@@ -0,0 +1,39 @@
+package main
+
+import (
+	"ai-notetaking-be/pkg/fakellm"
+	"ai-notetaking-be/pkg/logging"
+	"flag"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// fakellm serves stand-ins for the Gemini and ollama APIs, so the app runs
+// offline with GEMINI_BASE_URL and EMBEDDING_SERVER_BASE_URL pointed at it.
+func main() {
+	addr := flag.String("addr", ":11435", "listen address")
+	dimensions := flag.Int("dimensions", 768, "embedding dimensions")
+	apiKey := flag.String("api-key", "", "Gemini API key to require, any key is accepted when empty")
+	models := flag.String("models", "nomic-embed-text:v1.5,llama3.2", "comma separated ollama models to serve")
+	latency := flag.Duration("latency", 0, "delay added to every request")
+	tokenDelay := flag.Duration("token-delay", 0, "delay between streamed chat chunks")
+	errorRate := flag.Float64("error-rate", 0, "share of requests failed at random")
+	errorStatus := flag.Int("error-status", http.StatusTooManyRequests, "status of the random failures")
+	flag.Parse()
+	logging.Setup("text", "info")
+
+	server := fakellm.New(fakellm.Options{
+		Dimensions:  *dimensions,
+		ApiKey:      *apiKey,
+		Models:      strings.Split(*models, ","),
+		Latency:     *latency,
+		TokenDelay:  *tokenDelay,
+		ErrorRate:   *errorRate,
+		ErrorStatus: *errorStatus,
+	})
+
+	slog.Info("Serving fake Gemini and ollama APIs", "addr", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
@@ -1,73 +1,253 @@
 package main
 
 import (
+	"ai-notetaking-be/internal/config"
+	"ai-notetaking-be/internal/controller/apidoc"
+	"ai-notetaking-be/internal/controller/common"
+	healthcontroller "ai-notetaking-be/internal/controller/health"
 	notecontroller "ai-notetaking-be/internal/controller/note"
+	webhookcontroller "ai-notetaking-be/internal/controller/webhook"
+	"ai-notetaking-be/internal/metrics"
 	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	jobrepository "ai-notetaking-be/internal/repository/job"
 	noterepository "ai-notetaking-be/internal/repository/note"
+	webhookrepository "ai-notetaking-be/internal/repository/webhook"
 	"ai-notetaking-be/internal/service/consumer"
+	healthservice "ai-notetaking-be/internal/service/health"
 	noteservice "ai-notetaking-be/internal/service/note"
 	publisherservice "ai-notetaking-be/internal/service/publisher"
-	"ai-notetaking-be/pkg/database"
+	webhookservice "ai-notetaking-be/internal/service/webhook"
+	"ai-notetaking-be/internal/wiring"
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/lifecycle"
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/rabbitmq"
+	"ai-notetaking-be/pkg/tracing"
 	"context"
 	"log"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
 	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	godotenv.Load()
-	app := fiber.New()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "vector" {
+		err := runVector(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	app.Use(cors.New())
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.Setup(cfg.Log.Format, cfg.Log.Level)
+	lc := lifecycle.NewManager(cfg.ShutdownTimeout)
+	ctx := lc.Context()
+	shutdownTracing, err := tracing.Setup(
+		ctx,
+		"ai-notetaking-rest",
+		cfg.Tracing.Exporter,
+		cfg.Tracing.Endpoint,
+		cfg.Tracing.SampleRatio,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{
+		ErrorHandler: common.ErrorHandler,
+	})
 
-	db := database.ConnectDB(os.Getenv("DB_CONNECTION_STRING"))
-	// rabbitMqService := publisherservice.NewRabbitMqPublisherService(os.Getenv("RABBITMQ_CONNECTION_STRING"), "embed-note-content")
-	embeddingRepository := embeddingrepository.NewEmbeddingRepository(db)
+	appMetrics := metrics.New()
+	app.Use(appMetrics.HttpMiddleware())
+	app.Use(common.RequestLogger())
+	app.Use(common.Tracing())
+	app.Use(cors.New())
 
-	pubSubLogger := watermill.NewStdLogger(false, false)
-	pubsub := gochannel.NewGoChannel(gochannel.Config{}, pubSubLogger)
-	publisherService := publisherservice.NewInMemoryPublisherService(pubsub, "embed-note-content")
+	db, err := wiring.ConnectDatabase(ctx, cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	embeddingRepository := wiring.NewEmbeddingRepository(db, cfg.Database.VectorSearch)
+	warnWithoutVectorIndex(ctx, db, embeddingrepository.DistanceMetric(cfg.Database.VectorSearch.Metric))
 
 	noteRepository := noterepository.NewNoteRepository(db)
+	jobRepository := jobrepository.NewJobRepository(db)
+	events := wiring.NewEventConsumer(cfg, db, appMetrics, noteRepository, embeddingRepository)
+
+	healthCheck := func(name string, probe healthservice.Probe) *healthservice.Check {
+		return healthservice.NewCheck(name, probe, cfg.Health.ProbeInterval, cfg.Health.ProbeTimeout)
+	}
+	healthChecks := []*healthservice.Check{
+		healthCheck("database", healthservice.DatabaseProbe(db)),
+		healthCheck("pgvector", healthservice.VectorExtensionProbe(db)),
+		healthCheck("ollama", healthservice.OllamaProbe(cfg.Ollama.BaseUrl, cfg.Ollama.EmbeddingModel, cfg.Ollama.ChatModel)),
+	}
+
+	// Notes are embedded with gemini only where they are consumed, with rabbitmq
+	// that is cmd/embednoteconsumer.
+	if cfg.Queue.Driver != "rabbitmq" {
+		healthChecks = append(healthChecks, healthCheck("gemini", healthservice.EmbeddingProviderProbe(events.GeminiProvider)))
+	}
+
+	var interactivePublisher, bulkPublisher publisherservice.IPublisherService
+	var cons consumer.IEmbedNoteConsumerService
+	// Stays nil for the in memory queue, there is nothing to inspect.
+	var queueInspector healthservice.IQueueInspector
+	var brokerConn *rabbitmq.Connection
+	switch cfg.Queue.Driver {
+	case "postgres":
+		queueInspector = healthservice.NewPostgresQueueInspector(jobRepository)
+		interactivePublisher = publisherservice.NewPostgresPublisherService(jobRepository, cfg.Queue.Name)
+		bulkPublisher = publisherservice.NewPostgresPublisherService(jobRepository, cfg.Queue.BulkName())
+		cons = consumer.NewConsumerGroup(
+			consumer.NewPostgresConsumer(cfg.Queue.Name, db, jobRepository, events.Handler, events.Limiter),
+			consumer.NewPostgresConsumer(cfg.Queue.BulkName(), db, jobRepository, events.Handler, events.BulkLimiter),
+		)
+	case "rabbitmq":
+		// Consumed by cmd/embednoteconsumer.
+		interactivePublisher = publisherservice.NewRabbitMqPublisherService(cfg.RabbitMq.ConnectionString, cfg.Queue.Name)
+		bulkPublisher = publisherservice.NewRabbitMqPublisherService(cfg.RabbitMq.ConnectionString, cfg.Queue.BulkName())
+		cons = consumer.NewConsumerGroup()
+		brokerConn = rabbitmq.NewConnection(cfg.RabbitMq.ConnectionString, nil)
+		healthChecks = append(healthChecks, healthCheck("rabbitmq", healthservice.RabbitMqProbe(brokerConn)))
+		queueInspector = healthservice.NewRabbitMqQueueInspector(brokerConn)
+	default:
+		pubSubLogger := watermill.NewStdLogger(false, false)
+		pubsub := gochannel.NewGoChannel(gochannel.Config{}, pubSubLogger)
+		interactivePublisher = publisherservice.NewInMemoryPublisherService(pubsub, cfg.Queue.Name)
+		bulkPublisher = publisherservice.NewInMemoryPublisherService(pubsub, cfg.Queue.BulkName())
+		cons = consumer.NewConsumerGroup(
+			consumer.NewInMemoryConsumer(pubsub, cfg.Queue.Name, events.Handler, events.Limiter),
+			consumer.NewInMemoryConsumer(pubsub, cfg.Queue.BulkName(), events.Handler, events.BulkLimiter),
+		)
+	}
+
+	publisherService := publisherservice.NewLanePublisherService(
+		publisherservice.NewObservedPublisherService(
+			publisherservice.NewTracedPublisherService(interactivePublisher, cfg.Queue.Name),
+			appMetrics.ObservePublish(cfg.Queue.Name),
+		),
+		publisherservice.NewObservedPublisherService(
+			publisherservice.NewTracedPublisherService(bulkPublisher, cfg.Queue.BulkName()),
+			appMetrics.ObservePublish(cfg.Queue.BulkName()),
+		),
+	)
+
+	var queryCacheStore embedding.ICacheStore
+	if cfg.Embedding.QueryCache.Postgres {
+		queryCacheRepository := embeddingrepository.NewQueryEmbeddingCacheRepository(db)
+		queryCacheStore = queryCacheRepository
+		go purgeQueryEmbeddingCache(ctx, queryCacheRepository, time.Hour)
+	}
+	queryEmbeddingProvider := embedding.NewCachedProvider(
+		embedding.NewObservedProvider(
+			embedding.NewTracedProvider(embedding.NewOllamaProvider(cfg.Ollama.BaseUrl, cfg.Ollama.EmbeddingModel)),
+			appMetrics.ObserveEmbedding("ollama"),
+		),
+		embedding.CacheOptions{
+			Size:     cfg.Embedding.QueryCache.Size,
+			TTL:      cfg.Embedding.QueryCache.TTL,
+			Store:    queryCacheStore,
+			StoreTTL: cfg.Embedding.QueryCache.PostgresTTL,
+			Observe:  appMetrics.ObserveQueryCache,
+		},
+	)
+
 	notebookRepository := noterepository.NewNotebookRepository(db)
-	noteService := noteservice.NewNoteService(
+	noteService := noteservice.NewTracedNoteService(noteservice.NewNoteService(
 		noteRepository,
+		notebookRepository,
 		embeddingRepository,
 		publisherService,
-		os.Getenv("EMBEDDING_SERVER_BASE_URL"),
-		os.Getenv("EMBEDDING_MODEL_NAME"),
+		appMetrics,
+		queryEmbeddingProvider,
+		cfg.Ollama,
 		db,
-	)
-	notebookService := noteservice.NewNotebookService(
+	))
+	notebookService := noteservice.NewTracedNotebookService(noteservice.NewNotebookService(
 		notebookRepository,
 		noteRepository,
 		embeddingRepository,
 		publisherService,
 		db,
-	)
+	))
 	noteController := notecontroller.NewNoteController(noteService)
 	notebookController := notecontroller.NewNotebookController(notebookService)
 
-	notecontroller.AssignNoteRoutes(app, noteController, notebookController)
+	webhookService := webhookservice.NewTracedWebhookService(webhookservice.NewWebhookService(
+		webhookrepository.NewWebhookSubscriptionRepository(db),
+		webhookrepository.NewWebhookDeliveryRepository(db),
+		events.WebhookDispatcher,
+	))
+	webhookController := webhookcontroller.NewWebhookController(webhookService)
 
-	cons := consumer.NewInMemoryConsumer(
-		pubsub,
-		"embed-note-content",
-		db,
-		os.Getenv("EMBEDDING_SERVER_BASE_URL"),
-		os.Getenv("EMBEDDING_MODEL_NAME"),
+	healthService := healthservice.NewHealthService(
+		healthChecks,
+		queueInspector,
+		[]string{cfg.Queue.Name, cfg.Queue.BulkName()},
 		embeddingRepository,
-		noteRepository,
+		events.EmbeddingProvider.Model(),
 	)
-	err := cons.Consume(context.Background())
+	healthController := healthcontroller.NewHealthController(healthService)
+
+	app.Get("/metrics", appMetrics.Handler())
+	notecontroller.AssignNoteRoutes(app, noteController, notebookController)
+	webhookcontroller.AssignWebhookRoutes(app, webhookController)
+	healthcontroller.AssignHealthRoutes(app, healthController)
+	apidoc.AssignDocsRoutes(app, apidoc.NewDocument(
+		"AI Notetaking API",
+		"1.0.0",
+		notecontroller.ApiRoutes,
+		webhookcontroller.ApiRoutes,
+		healthcontroller.ApiRoutes,
+	))
+
+	go events.WebhookDispatcher.Run(ctx)
+	err = cons.Consume(ctx)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	log.Fatal(app.Listen(":3000"))
+	lc.OnShutdown("http server", app.ShutdownWithContext)
+	lc.OnShutdown("embedding consumer", cons.Shutdown)
+	events.OnShutdown(lc)
+	lc.OnShutdown("publishers", publisherService.Close)
+	if brokerConn != nil {
+		lc.OnShutdown("rabbitmq health connection", func(ctx context.Context) error {
+			return brokerConn.Close()
+		})
+	}
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		db.Close()
+		return nil
+	})
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	go func() {
+		err := app.Listen(cfg.Http.Addr)
+		if err != nil {
+			slog.Error("HTTP server stopped", "error", err)
+		}
+		lc.Stop()
+	}()
+
+	err = lc.Wait()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
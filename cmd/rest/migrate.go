@@ -0,0 +1,73 @@
+package main
+
+import (
+	"ai-notetaking-be/internal/config"
+	"ai-notetaking-be/migrations"
+	"ai-notetaking-be/pkg/database"
+	"ai-notetaking-be/pkg/migration"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const migrateUsage = "usage: rest migrate up | down [steps] | status"
+
+// runMigrate implements `rest migrate`, it only needs the database settings.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	db := database.ConnectDB(cfg.ConnectionString)
+	defer db.Close()
+
+	migrator, err := migration.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Version: %d (dirty: %t)\n", status.Version, status.Dirty)
+		for _, m := range status.Migrations {
+			state := "pending"
+			if m.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%-8s %d_%s\n", state, m.Version, m.Name)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
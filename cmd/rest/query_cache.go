@@ -0,0 +1,30 @@
+package main
+
+import (
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// purgeQueryEmbeddingCache deletes expired cache rows every interval, lookups
+// already skip them but nothing else would ever remove them.
+func purgeQueryEmbeddingCache(ctx context.Context, repository embeddingrepository.IQueryEmbeddingCacheRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := repository.DeleteExpired(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Purging the query embedding cache failed", "error", err)
+			continue
+		}
+		slog.DebugContext(ctx, "Purged the query embedding cache", "deleted", deleted)
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"ai-notetaking-be/internal/config"
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	pgvector "github.com/pgvector/pgvector-go"
+)
+
+const vectorUsage = `usage: rest vector status
+       rest vector index hnsw|ivfflat [-metric m] [-m n] [-ef-construction n] [-lists n]
+       rest vector drop <index>
+       rest vector bench [-queries n] [-k n] [-ef-search 20,40,...] [-probes 1,10,...]`
+
+// runVector implements `rest vector`, managing the approximate nearest
+// neighbour indexes of embedding_notes and measuring how well they do.
+func runVector(args []string) error {
+	if len(args) == 0 {
+		return errors.New(vectorUsage)
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	metric := embeddingrepository.DistanceMetric(cfg.VectorSearch.Metric)
+	db := database.ConnectDB(cfg.ConnectionString)
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "status":
+		return vectorStatus(ctx, db, metric)
+	case "index":
+		return vectorIndex(ctx, db, metric, args[1:])
+	case "drop":
+		if len(args) != 2 {
+			return errors.New(vectorUsage)
+		}
+		err = embeddingrepository.DropVectorIndex(ctx, db, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Dropped %s\n", args[1])
+	case "bench":
+		return vectorBench(ctx, db, metric, cfg.VectorSearch, args[1:])
+	default:
+		return errors.New(vectorUsage)
+	}
+
+	return nil
+}
+
+func vectorStatus(ctx context.Context, db *pgxpool.Pool, metric embeddingrepository.DistanceMetric) error {
+	indexes, err := embeddingrepository.ListVectorIndexes(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Metric: %s (%s, %s)\n", metric, metric.Operator(), metric.OperatorClass())
+	for _, index := range indexes {
+		serves := ""
+		if index.OperatorClass == metric.OperatorClass() {
+			serves = "serves searches"
+		}
+		fmt.Printf("%-8s %-18s %-50s %s\n", index.Method, index.OperatorClass, index.Name, serves)
+	}
+	if !embeddingrepository.ServesMetric(indexes, metric) {
+		fmt.Println("No index serves the metric, searches compare every embedding")
+	}
+
+	return nil
+}
+
+func vectorIndex(ctx context.Context, db *pgxpool.Pool, metric embeddingrepository.DistanceMetric, args []string) error {
+	if len(args) == 0 {
+		return errors.New(vectorUsage)
+	}
+	flags := flag.NewFlagSet("index", flag.ContinueOnError)
+	metricName := flags.String("metric", string(metric), "distance metric the index serves")
+	m := flags.Int("m", 0, "HNSW connections per node")
+	efConstruction := flags.Int("ef-construction", 0, "HNSW candidate list size while building")
+	lists := flags.Int("lists", 0, "IVFFlat partitions")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+	indexMetric, err := embeddingrepository.ParseDistanceMetric(*metricName)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	name, err := embeddingrepository.CreateVectorIndex(ctx, db, embeddingrepository.VectorIndexOptions{
+		Method:         args[0],
+		Metric:         indexMetric,
+		M:              *m,
+		EfConstruction: *efConstruction,
+		Lists:          *lists,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Built %s in %s\n", name, time.Since(start).Round(time.Millisecond))
+	if indexMetric != metric {
+		fmt.Printf("Searches use %s, set DB_VECTOR_METRIC=%s for them to use this index\n", metric, indexMetric)
+	}
+
+	return nil
+}
+
+type benchResult struct {
+	name      string
+	recall    float64
+	latencies []time.Duration
+}
+
+// vectorBench searches for the embeddings of random notes, exactly and with
+// every ef_search and probes value, and reports the recall of the approximate
+// searches against the exact one together with their latencies.
+func vectorBench(ctx context.Context, db *pgxpool.Pool, metric embeddingrepository.DistanceMetric, cfg config.VectorSearchConfig, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	queries := flags.Int("queries", 100, "number of sampled query embeddings")
+	k := flags.Int("k", 10, "neighbours per search")
+	efSearch := flags.String("ef-search", "", "comma separated HNSW ef_search values, the configured one when empty")
+	probes := flags.String("probes", "", "comma separated IVFFlat probes values")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	efSearchValues, err := parseInts(*efSearch, cfg.EfSearch)
+	if err != nil {
+		return err
+	}
+	probesValues, err := parseInts(*probes, cfg.Probes)
+	if err != nil {
+		return err
+	}
+
+	samples, err := sampleEmbeddings(ctx, db, *queries)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return errors.New("embedding_notes has no live embeddings to search for")
+	}
+	warnWithoutVectorIndex(ctx, db, metric)
+	// Settings come from the options alone, so 0 means the server default.
+	repository := embeddingrepository.NewEmbeddingRepository(db, metric, embeddingrepository.SearchOptions{})
+
+	exact := make([][]uuid.UUID, 0, len(samples))
+	exactResult := benchResult{name: "exact", recall: 1}
+	for _, sample := range samples {
+		ids, latency, err := benchSearch(ctx, repository, sample, embeddingrepository.SearchOptions{Limit: *k, Exact: true})
+		if err != nil {
+			return err
+		}
+		exact = append(exact, ids)
+		exactResult.latencies = append(exactResult.latencies, latency)
+	}
+
+	results := []benchResult{exactResult}
+	settings := make([]embeddingrepository.SearchOptions, 0)
+	for _, ef := range efSearchValues {
+		for _, p := range probesValues {
+			settings = append(settings, embeddingrepository.SearchOptions{Limit: *k, EfSearch: ef, Probes: p})
+		}
+	}
+	for _, options := range settings {
+		result := benchResult{name: settingName(options)}
+		found, total := 0, 0
+		for i, sample := range samples {
+			ids, latency, err := benchSearch(ctx, repository, sample, options)
+			if err != nil {
+				return err
+			}
+			result.latencies = append(result.latencies, latency)
+			for _, id := range exact[i] {
+				if slices.Contains(ids, id) {
+					found++
+				}
+			}
+			total += len(exact[i])
+		}
+		result.recall = float64(found) / float64(max(total, 1))
+		results = append(results, result)
+	}
+
+	fmt.Printf("Metric %s, %d queries, k = %d\n", metric, len(samples), *k)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "search\trecall@%d\tmean\tp50\tp95\tp99\n", *k)
+	for _, result := range results {
+		slices.Sort(result.latencies)
+		fmt.Fprintf(
+			w,
+			"%s\t%.3f\t%s\t%s\t%s\t%s\n",
+			result.name,
+			result.recall,
+			mean(result.latencies),
+			percentile(result.latencies, 0.5),
+			percentile(result.latencies, 0.95),
+			percentile(result.latencies, 0.99),
+		)
+	}
+
+	return w.Flush()
+}
+
+func benchSearch(ctx context.Context, repository embeddingrepository.IEmbeddingRepository, embedding []float32, options embeddingrepository.SearchOptions) ([]uuid.UUID, time.Duration, error) {
+	start := time.Now()
+	notes, err := repository.SearchNearest(ctx, embedding, options)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.NoteId)
+	}
+
+	return ids, latency, nil
+}
+
+func sampleEmbeddings(ctx context.Context, db *pgxpool.Pool, n int) ([][]float32, error) {
+	rows, err := db.Query(ctx, "SELECT embedding::text FROM embedding_notes WHERE is_deleted = false ORDER BY random() LIMIT $1", n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([][]float32, 0, n)
+	for rows.Next() {
+		var text string
+		err = rows.Scan(&text)
+		if err != nil {
+			return nil, err
+		}
+		var vector pgvector.Vector
+		err = vector.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, vector.Slice())
+	}
+
+	return samples, rows.Err()
+}
+
+func settingName(options embeddingrepository.SearchOptions) string {
+	parts := make([]string, 0)
+	if options.EfSearch > 0 {
+		parts = append(parts, fmt.Sprintf("ef_search=%d", options.EfSearch))
+	}
+	if options.Probes > 0 {
+		parts = append(parts, fmt.Sprintf("probes=%d", options.Probes))
+	}
+	if len(parts) == 0 {
+		return "index defaults"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// parseInts reads a comma separated list, fallback alone when s is empty.
+func parseInts(s string, fallback int) ([]int, error) {
+	if s == "" {
+		return []int{fallback}, nil
+	}
+
+	values := make([]int, 0)
+	for _, part := range strings.Split(s, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid value %q in %q", part, s)
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func mean(latencies []time.Duration) time.Duration {
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+
+	return (sum / time.Duration(max(len(latencies), 1))).Round(time.Microsecond)
+}
+
+// percentile expects sorted latencies.
+func percentile(latencies []time.Duration, q float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	return latencies[int(float64(len(latencies)-1)*q)].Round(time.Microsecond)
+}
+
+// warnWithoutVectorIndex points out that searches will scan every embedding,
+// usually because DB_VECTOR_METRIC was changed without building its index.
+func warnWithoutVectorIndex(ctx context.Context, db *pgxpool.Pool, metric embeddingrepository.DistanceMetric) {
+	indexes, err := embeddingrepository.ListVectorIndexes(ctx, db)
+	if err != nil {
+		slog.WarnContext(ctx, "Listing vector indexes failed", "error", err)
+		return
+	}
+	if !embeddingrepository.ServesMetric(indexes, metric) {
+		slog.WarnContext(ctx, "No vector index serves the distance metric, searches compare every embedding, see `rest vector index`", "metric", metric)
+	}
+}
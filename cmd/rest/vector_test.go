@@ -0,0 +1,37 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseInts(t *testing.T) {
+	cases := []struct {
+		name     string
+		s        string
+		fallback int
+		want     []int
+		wantErr  bool
+	}{
+		{"empty uses the fallback", "", 40, []int{40}, false},
+		{"single value", "100", 40, []int{100}, false},
+		{"list", "20,40,80", 0, []int{20, 40, 80}, false},
+		{"spaces around values", " 1, 10 ,100", 0, []int{1, 10, 100}, false},
+		{"zero keeps the default", "0", 40, []int{0}, false},
+		{"negative", "20,-1", 0, nil, true},
+		{"not a number", "20,abc", 0, nil, true},
+		{"empty element", "20,,40", 0, nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseInts(c.s, c.fallback)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("parseInts(%q) error = %v, want error %t", c.s, err, c.wantErr)
+			}
+			if !slices.Equal(got, c.want) {
+				t.Fatalf("parseInts(%q) = %v, want %v", c.s, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package config
+
+import (
+	"ai-notetaking-be/pkg/env"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// Profile picks config.<profile>.yaml on top of the base file, from APP_ENV.
+	// Without one only the base file applies.
+	Profile         string          `yaml:"-"`
+	Http            HttpConfig      `yaml:"http"`
+	Database        DatabaseConfig  `yaml:"database"`
+	Queue           QueueConfig     `yaml:"queue"`
+	RabbitMq        RabbitMqConfig  `yaml:"rabbitmq"`
+	Gemini          GeminiConfig    `yaml:"gemini"`
+	Ollama          OllamaConfig    `yaml:"ollama"`
+	Embedding       EmbeddingConfig `yaml:"embedding"`
+	Consumer        ConsumerConfig  `yaml:"consumer"`
+	Log             LogConfig       `yaml:"log"`
+	Metrics         MetricsConfig   `yaml:"metrics"`
+	Tracing         TracingConfig   `yaml:"tracing"`
+	Health          HealthConfig    `yaml:"health"`
+	ShutdownTimeout time.Duration   `yaml:"shutdown_timeout"`
+
+	// envErr holds the environment variables that did not parse, reported by Validate.
+	envErr error
+}
+
+type HttpConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+type DatabaseConfig struct {
+	ConnectionString string `yaml:"connection_string"`
+	// MigrateOnStartup applies pending migrations before serving, replicas
+	// starting together take turns through an advisory lock.
+	MigrateOnStartup bool               `yaml:"migrate_on_startup"`
+	VectorSearch     VectorSearchConfig `yaml:"vector_search"`
+}
+
+type VectorSearchConfig struct {
+	// Metric is cosine, inner_product or l2, the vector index has to be built
+	// for the same one, see `rest vector status`.
+	Metric string `yaml:"metric"`
+	// EfSearch and Probes tune HNSW and IVFFlat searches, 0 keeps the
+	// server defaults.
+	EfSearch int `yaml:"ef_search"`
+	Probes   int `yaml:"probes"`
+}
+
+type QueueConfig struct {
+	// Driver is inmemory, postgres or rabbitmq.
+	Driver string `yaml:"driver"`
+	Name   string `yaml:"name"`
+}
+
+// BulkName is the queue of the low priority lane.
+func (q QueueConfig) BulkName() string {
+	return q.Name + ".bulk"
+}
+
+type RabbitMqConfig struct {
+	ConnectionString string `yaml:"connection_string"`
+}
+
+type GeminiConfig struct {
+	BaseUrl string `yaml:"base_url"`
+	ApiKey  string `yaml:"api_key"`
+	// RateLimit is in requests per second, 0 disables the limit.
+	RateLimit float64 `yaml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst"`
+}
+
+type OllamaConfig struct {
+	BaseUrl        string `yaml:"base_url"`
+	EmbeddingModel string `yaml:"embedding_model"`
+	ChatModel      string `yaml:"chat_model"`
+}
+
+type EmbeddingConfig struct {
+	BatchSize        int              `yaml:"batch_size"`
+	BatchWait        time.Duration    `yaml:"batch_wait"`
+	DebounceWindow   time.Duration    `yaml:"debounce_window"`
+	DebounceMaxDelay time.Duration    `yaml:"debounce_max_delay"`
+	QueryCache       QueryCacheConfig `yaml:"query_cache"`
+}
+
+// QueryCacheConfig caches the embeddings of search and ask queries, in process
+// and optionally in postgres where every instance shares them.
+type QueryCacheConfig struct {
+	// Size 0 disables the in process tier.
+	Size        int           `yaml:"size"`
+	TTL         time.Duration `yaml:"ttl"`
+	Postgres    bool          `yaml:"postgres"`
+	PostgresTTL time.Duration `yaml:"postgres_ttl"`
+}
+
+type ConsumerConfig struct {
+	MaxConcurrent int `yaml:"max_concurrent"`
+	MinConcurrent int `yaml:"min_concurrent"`
+	// Prefetch is the RabbitMQ QoS, 0 means MaxConcurrent.
+	Prefetch          int           `yaml:"prefetch"`
+	Adaptive          bool          `yaml:"adaptive"`
+	LatencyTarget     time.Duration `yaml:"latency_target"`
+	BulkMaxConcurrent int           `yaml:"bulk_max_concurrent"`
+}
+
+type LogConfig struct {
+	// Format is json or text.
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+}
+
+type MetricsConfig struct {
+	// Addr is where cmd/embednoteconsumer serves /metrics and the health
+	// endpoints, the rest server serves them next to the API.
+	Addr string `yaml:"addr"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the host:port of the OTLP/HTTP collector.
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	// ProbeInterval is how long a readiness probe result is reused.
+	ProbeInterval time.Duration `yaml:"probe_interval"`
+	ProbeTimeout  time.Duration `yaml:"probe_timeout"`
+}
+
+var traceExporters = []string{"none", "stdout", "otlp"}
+
+var queueDrivers = []string{"inmemory", "postgres", "rabbitmq"}
+
+var vectorMetrics = []string{"cosine", "inner_product", "l2"}
+
+func defaults() *Config {
+	return &Config{
+		Http: HttpConfig{
+			Addr: ":3000",
+		},
+		Database: DatabaseConfig{
+			VectorSearch: VectorSearchConfig{
+				Metric: "cosine",
+			},
+		},
+		Queue: QueueConfig{
+			Driver: "inmemory",
+			Name:   "embed-note-content",
+		},
+		Gemini: GeminiConfig{
+			BaseUrl:   "https://generativelanguage.googleapis.com",
+			RateBurst: 1,
+		},
+		Ollama: OllamaConfig{
+			BaseUrl:        "http://localhost:11434",
+			EmbeddingModel: "nomic-embed-text:v1.5",
+			ChatModel:      "llama3.2",
+		},
+		Embedding: EmbeddingConfig{
+			BatchSize:        32,
+			BatchWait:        50 * time.Millisecond,
+			DebounceWindow:   3 * time.Second,
+			DebounceMaxDelay: 30 * time.Second,
+			QueryCache: QueryCacheConfig{
+				Size:        1000,
+				TTL:         time.Hour,
+				PostgresTTL: 7 * 24 * time.Hour,
+			},
+		},
+		Consumer: ConsumerConfig{
+			MaxConcurrent:     100,
+			MinConcurrent:     1,
+			LatencyTarget:     2 * time.Second,
+			BulkMaxConcurrent: 1,
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Metrics: MetricsConfig{
+			Addr: ":9090",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			ProbeInterval: 30 * time.Second,
+			ProbeTimeout:  5 * time.Second,
+		},
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// Load builds the configuration from, in increasing precedence, the defaults,
+// the YAML file at CONFIG_FILE (config/config.yaml), the profile file next to
+// it, .env and the environment. The result is validated.
+func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadDatabase only requires the database settings, for commands such as
+// migrate that run without the rest of the service configured.
+func LoadDatabase() (*DatabaseConfig, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	err = errors.Join(append(cfg.Database.validate(), cfg.envErr)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg.Database, nil
+}
+
+func load() (*Config, error) {
+	godotenv.Load()
+
+	cfg := defaults()
+	cfg.Profile = env.String("APP_ENV", "")
+
+	path := env.String("CONFIG_FILE", filepath.Join("config", "config.yaml"))
+	err := loadFile(path, cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	// A chosen profile has to exist, a typo would otherwise run with the base
+	// settings only.
+	if cfg.Profile != "" {
+		profilePath := filepath.Join(filepath.Dir(path), fmt.Sprintf("config.%s.yaml", cfg.Profile))
+		err = loadFile(profilePath, cfg, true)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q (APP_ENV): %w", cfg.Profile, err)
+		}
+	}
+
+	cfg.envErr = cfg.applyEnv()
+	if cfg.Consumer.Prefetch == 0 {
+		cfg.Consumer.Prefetch = cfg.Consumer.MaxConcurrent
+	}
+
+	return cfg, nil
+}
+
+// loadFile overlays the values present in the file, a missing file is skipped
+// unless it is required.
+func loadFile(path string, cfg *Config, required bool) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = yaml.Unmarshal(content, cfg)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv overlays the environment and returns the variables that did not parse.
+func (c *Config) applyEnv() error {
+	r := &env.Reader{}
+	c.Http.Addr = r.String("HTTP_ADDR", c.Http.Addr)
+	c.Database.ConnectionString = r.String("DB_CONNECTION_STRING", c.Database.ConnectionString)
+	c.Database.MigrateOnStartup = r.Bool("DB_MIGRATE_ON_STARTUP", c.Database.MigrateOnStartup)
+	c.Database.VectorSearch.Metric = r.String("DB_VECTOR_METRIC", c.Database.VectorSearch.Metric)
+	c.Database.VectorSearch.EfSearch = r.Int("DB_VECTOR_EF_SEARCH", c.Database.VectorSearch.EfSearch)
+	c.Database.VectorSearch.Probes = r.Int("DB_VECTOR_PROBES", c.Database.VectorSearch.Probes)
+	c.Queue.Driver = r.String("QUEUE_DRIVER", c.Queue.Driver)
+	c.Queue.Name = r.String("QUEUE_NAME", c.Queue.Name)
+	c.RabbitMq.ConnectionString = r.String("RABBITMQ_CONNECTION_STRING", c.RabbitMq.ConnectionString)
+	c.Gemini.BaseUrl = r.String("GEMINI_BASE_URL", c.Gemini.BaseUrl)
+	c.Gemini.ApiKey = r.String("GEMINI_API_KEY", c.Gemini.ApiKey)
+	c.Gemini.RateLimit = r.Float("GEMINI_RATE_LIMIT", c.Gemini.RateLimit)
+	c.Gemini.RateBurst = r.Int("GEMINI_RATE_BURST", c.Gemini.RateBurst)
+	c.Ollama.BaseUrl = r.String("EMBEDDING_SERVER_BASE_URL", c.Ollama.BaseUrl)
+	c.Ollama.EmbeddingModel = r.String("EMBEDDING_MODEL_NAME", c.Ollama.EmbeddingModel)
+	c.Ollama.ChatModel = r.String("CHAT_MODEL_NAME", c.Ollama.ChatModel)
+	c.Embedding.BatchSize = r.Int("EMBEDDING_BATCH_SIZE", c.Embedding.BatchSize)
+	c.Embedding.BatchWait = r.Duration("EMBEDDING_BATCH_WAIT", c.Embedding.BatchWait)
+	c.Embedding.DebounceWindow = r.Duration("EMBEDDING_DEBOUNCE_WINDOW", c.Embedding.DebounceWindow)
+	c.Embedding.DebounceMaxDelay = r.Duration("EMBEDDING_DEBOUNCE_MAX_DELAY", c.Embedding.DebounceMaxDelay)
+	c.Embedding.QueryCache.Size = r.Int("EMBEDDING_QUERY_CACHE_SIZE", c.Embedding.QueryCache.Size)
+	c.Embedding.QueryCache.TTL = r.Duration("EMBEDDING_QUERY_CACHE_TTL", c.Embedding.QueryCache.TTL)
+	c.Embedding.QueryCache.Postgres = r.Bool("EMBEDDING_QUERY_CACHE_POSTGRES", c.Embedding.QueryCache.Postgres)
+	c.Embedding.QueryCache.PostgresTTL = r.Duration("EMBEDDING_QUERY_CACHE_POSTGRES_TTL", c.Embedding.QueryCache.PostgresTTL)
+	c.Consumer.MaxConcurrent = r.Int("CONSUMER_MAX_CONCURRENT", c.Consumer.MaxConcurrent)
+	c.Consumer.MinConcurrent = r.Int("CONSUMER_MIN_CONCURRENT", c.Consumer.MinConcurrent)
+	c.Consumer.Prefetch = r.Int("CONSUMER_PREFETCH", c.Consumer.Prefetch)
+	c.Consumer.Adaptive = r.Bool("CONSUMER_ADAPTIVE_CONCURRENCY", c.Consumer.Adaptive)
+	c.Consumer.LatencyTarget = r.Duration("CONSUMER_LATENCY_TARGET", c.Consumer.LatencyTarget)
+	c.Consumer.BulkMaxConcurrent = r.Int("CONSUMER_BULK_MAX_CONCURRENT", c.Consumer.BulkMaxConcurrent)
+	c.Log.Format = r.String("LOG_FORMAT", c.Log.Format)
+	c.Log.Level = r.String("LOG_LEVEL", c.Log.Level)
+	c.Metrics.Addr = r.String("METRICS_ADDR", c.Metrics.Addr)
+	c.Tracing.Exporter = r.String("TRACING_EXPORTER", c.Tracing.Exporter)
+	c.Tracing.Endpoint = r.String("TRACING_ENDPOINT", c.Tracing.Endpoint)
+	c.Tracing.SampleRatio = r.Float("TRACING_SAMPLE_RATIO", c.Tracing.SampleRatio)
+	c.Health.ProbeInterval = r.Duration("HEALTH_PROBE_INTERVAL", c.Health.ProbeInterval)
+	c.Health.ProbeTimeout = r.Duration("HEALTH_PROBE_TIMEOUT", c.Health.ProbeTimeout)
+	c.ShutdownTimeout = r.Duration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+
+	return r.Err()
+}
+
+func (d *DatabaseConfig) validate() []error {
+	var errs []error
+	if d.ConnectionString == "" {
+		errs = append(errs, errors.New("database.connection_string (DB_CONNECTION_STRING) is required"))
+	}
+	if !slices.Contains(vectorMetrics, d.VectorSearch.Metric) {
+		errs = append(errs, fmt.Errorf("database.vector_search.metric (DB_VECTOR_METRIC) must be one of %v, got %q", vectorMetrics, d.VectorSearch.Metric))
+	}
+	if d.VectorSearch.EfSearch < 0 || d.VectorSearch.Probes < 0 {
+		errs = append(errs, errors.New("database.vector_search.ef_search and probes (DB_VECTOR_EF_SEARCH, DB_VECTOR_PROBES) can not be negative"))
+	}
+
+	return errs
+}
+
+// Validate reports every missing or invalid value at once, so a bad deployment
+// fails at startup instead of on the first request that needs the value.
+func (c *Config) Validate() error {
+	errs := append(c.Database.validate(), c.envErr)
+	if c.Gemini.BaseUrl == "" {
+		errs = append(errs, errors.New("gemini.base_url (GEMINI_BASE_URL) is required"))
+	}
+	if c.Gemini.ApiKey == "" {
+		errs = append(errs, errors.New("gemini.api_key (GEMINI_API_KEY) is required"))
+	}
+	if !slices.Contains(queueDrivers, c.Queue.Driver) {
+		errs = append(errs, fmt.Errorf("queue.driver (QUEUE_DRIVER) must be one of %v, got %q", queueDrivers, c.Queue.Driver))
+	}
+	if c.Queue.Name == "" {
+		errs = append(errs, errors.New("queue.name (QUEUE_NAME) is required"))
+	}
+	if c.Queue.Driver == "rabbitmq" && c.RabbitMq.ConnectionString == "" {
+		errs = append(errs, errors.New("rabbitmq.connection_string (RABBITMQ_CONNECTION_STRING) is required by the rabbitmq driver"))
+	}
+	if c.Ollama.BaseUrl == "" {
+		errs = append(errs, errors.New("ollama.base_url (EMBEDDING_SERVER_BASE_URL) is required"))
+	}
+	if c.Embedding.QueryCache.Size < 0 || c.Embedding.QueryCache.TTL <= 0 || c.Embedding.QueryCache.PostgresTTL <= 0 {
+		errs = append(errs, errors.New("embedding.query_cache.size can not be negative and its ttl and postgres_ttl must be positive"))
+	}
+	if !slices.Contains(traceExporters, c.Tracing.Exporter) {
+		errs = append(errs, fmt.Errorf("tracing.exporter (TRACING_EXPORTER) must be one of %v, got %q", traceExporters, c.Tracing.Exporter))
+	}
+	if c.Consumer.MaxConcurrent < 1 || c.Consumer.BulkMaxConcurrent < 1 {
+		errs = append(errs, errors.New("consumer.max_concurrent and consumer.bulk_max_concurrent must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
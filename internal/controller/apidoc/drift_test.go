@@ -0,0 +1,107 @@
+package apidoc_test
+
+import (
+	"ai-notetaking-be/internal/controller/apidoc"
+	healthcontroller "ai-notetaking-be/internal/controller/health"
+	notecontroller "ai-notetaking-be/internal/controller/note"
+	webhookcontroller "ai-notetaking-be/internal/controller/webhook"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newDocument() *apidoc.Document {
+	return apidoc.NewDocument("test", "test", notecontroller.ApiRoutes, webhookcontroller.ApiRoutes, healthcontroller.ApiRoutes)
+}
+
+func registeredRoutes() []string {
+	app := fiber.New()
+	notecontroller.AssignNoteRoutes(
+		app,
+		notecontroller.NewNoteController(nil),
+		notecontroller.NewNotebookController(nil),
+	)
+	webhookcontroller.AssignWebhookRoutes(app, webhookcontroller.NewWebhookController(nil))
+	healthcontroller.AssignHealthRoutes(app, healthcontroller.NewHealthController(nil))
+
+	params := regexp.MustCompile(`:(\w+)`)
+	routes := make([]string, 0)
+	for _, route := range app.GetRoutes(true) {
+		// fiber registers a HEAD route for every GET.
+		if route.Method == http.MethodHead {
+			continue
+		}
+		path := params.ReplaceAllString(strings.TrimSuffix(route.Path, "/"), "{$1}")
+		routes = append(routes, route.Method+" "+path)
+	}
+
+	return routes
+}
+
+func documentedRoutes(doc *apidoc.Document) []string {
+	routes := make([]string, 0)
+	for path, item := range doc.Paths {
+		for method := range item {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	return routes
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	documented := documentedRoutes(newDocument())
+	for _, route := range registeredRoutes() {
+		if !slices.Contains(documented, route) {
+			t.Errorf("%s is served but missing from the OpenAPI document", route)
+		}
+	}
+}
+
+func TestEveryDocumentedRouteIsServed(t *testing.T) {
+	registered := registeredRoutes()
+	for _, route := range documentedRoutes(newDocument()) {
+		if !slices.Contains(registered, route) {
+			t.Errorf("%s is documented but not served", route)
+		}
+	}
+}
+
+func TestSchemaReferencesResolve(t *testing.T) {
+	doc := newDocument()
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := regexp.MustCompile(`"\$ref":"#/components/schemas/(\w+)"`).FindAllSubmatch(body, -1)
+	if len(refs) == 0 {
+		t.Fatal("document has no schema references")
+	}
+	for _, ref := range refs {
+		if _, ok := doc.Components.Schemas[string(ref[1])]; !ok {
+			t.Errorf("schema %s is referenced but not defined", ref[1])
+		}
+	}
+}
+
+func TestQueryParametersAreDocumented(t *testing.T) {
+	doc := newDocument()
+	for path, name := range map[string]string{
+		"/api/v1/note":     "query",
+		"/api/v1/note/ask": "question",
+	} {
+		operation := doc.Paths[path]["get"]
+		found := slices.ContainsFunc(operation.Parameters, func(p apidoc.Parameter) bool {
+			return p.In == "query" && p.Name == name && p.Required
+		})
+		if !found {
+			t.Errorf("GET %s does not document the required %q query parameter", path, name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package apidoc
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+const swaggerUiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>API docs</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+	</script>
+</body>
+</html>`
+
+// AssignDocsRoutes serves the document at /api/openapi.json and Swagger UI at /api/docs.
+func AssignDocsRoutes(app *fiber.App, doc *Document) {
+	app.Get("/api/openapi.json", func(c *fiber.Ctx) error {
+		return c.JSON(doc)
+	})
+	app.Get("/api/docs", func(c *fiber.Ctx) error {
+		c.Type("html")
+		return c.SendString(swaggerUiPage)
+	})
+}
@@ -0,0 +1,178 @@
+package apidoc
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// schemaRegistry turns DTO types into schemas, structs become named components
+// referenced from operations.
+type schemaRegistry struct {
+	schemas map[string]*Schema
+}
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *Schema
+	switch {
+	case t == uuidType:
+		schema = &Schema{Type: "string", Format: "uuid"}
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		// References can not carry siblings in 3.0, a nullable struct is left as is.
+		return &Schema{Ref: "#/components/schemas/" + r.register(t)}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema = &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Map:
+		schema = &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case t.Kind() == reflect.String:
+		schema = &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		schema = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema = &Schema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = &Schema{Type: "number"}
+	default:
+		schema = &Schema{}
+	}
+	schema.Nullable = nullable
+
+	return schema
+}
+
+func (r *schemaRegistry) register(t reflect.Type) string {
+	name := t.Name()
+	if _, ok := r.schemas[name]; ok {
+		return name
+	}
+
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	// Registered before the fields so self references terminate.
+	r.schemas[name] = schema
+	for _, field := range fields(t, "json") {
+		property := r.schemaOf(field.Type)
+		if applyRules(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, field.Name)
+		}
+		schema.Properties[field.Name] = property
+	}
+
+	return name
+}
+
+// queryParameters describes a DTO parsed with QueryParser.
+func (r *schemaRegistry) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	params := make([]Parameter, 0)
+	for _, field := range fields(t, "query") {
+		schema := r.schemaOf(field.Type)
+		required := applyRules(schema, field.Tag.Get("validate"))
+		params = append(params, Parameter{
+			Name:     field.Name,
+			In:       "query",
+			Required: required,
+			Schema:   schema,
+		})
+	}
+
+	return params
+}
+
+type namedField struct {
+	Name string
+	Type reflect.Type
+	Tag  reflect.StructTag
+}
+
+func fields(t reflect.Type, tagName string) []namedField {
+	result := make([]namedField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		result = append(result, namedField{
+			Name: name,
+			Type: field.Type,
+			Tag:  field.Tag,
+		})
+	}
+
+	return result
+}
+
+// applyRules mirrors the validate tags understood by pkg/validation onto the
+// schema. Returns whether the field is required.
+func applyRules(schema *Schema, rules string) bool {
+	required := false
+	target := schema
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "notblank":
+			required = true
+			target.MinLength = intPtr(1)
+		case "min":
+			n, _ := strconv.Atoi(param)
+			if target.Type == "integer" {
+				target.Minimum = intPtr(n)
+			}
+		case "max":
+			n, _ := strconv.Atoi(param)
+			switch target.Type {
+			case "array":
+				target.MaxItems = intPtr(n)
+			case "integer":
+				target.Maximum = intPtr(n)
+			default:
+				target.MaxLength = intPtr(n)
+			}
+		case "http_url", "url":
+			target.Format = "uri"
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "dive":
+			// Rules after dive apply to the elements.
+			if target.Items != nil {
+				target = target.Items
+			}
+		}
+	}
+
+	return required
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,144 @@
+package apidoc
+
+import (
+	"ai-notetaking-be/internal/controller/common"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Route documents one handler. Controller packages list their routes next to
+// routes.go, the schemas are generated from the DTO types given here.
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	// Query is the DTO the handler fills with QueryParser, Body the one it fills
+	// with BodyParser and Response the one it answers with. nil when unused.
+	Query    any
+	Body     any
+	Response any
+	Status   int
+	// Errors lists statuses on top of the ones derived from the route.
+	Errors []int
+}
+
+func NewDocument(title string, version string, routes ...[]Route) *Document {
+	registry := &schemaRegistry{schemas: make(map[string]*Schema)}
+	errorSchema := registry.schemaOf(reflect.TypeOf(common.ErrorResponse{}))
+
+	doc := &Document{
+		OpenApi: "3.0.3",
+		Info: Info{
+			Title:   title,
+			Version: version,
+		},
+		Paths: make(map[string]PathItem),
+	}
+	for _, group := range routes {
+		for _, route := range group {
+			path, params := openApiPath(route.Path)
+			item, ok := doc.Paths[path]
+			if !ok {
+				item = make(PathItem)
+				doc.Paths[path] = item
+			}
+			item[strings.ToLower(route.Method)] = newOperation(registry, route, params, errorSchema)
+		}
+	}
+	doc.Components.Schemas = registry.schemas
+
+	return doc
+}
+
+func newOperation(registry *schemaRegistry, route Route, pathParams []string, errorSchema *Schema) *Operation {
+	operation := &Operation{
+		OperationId: operationId(route),
+		Summary:     route.Summary,
+		Responses:   make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+
+	for _, name := range pathParams {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid"},
+		})
+	}
+	if route.Query != nil {
+		operation.Parameters = append(operation.Parameters, registry.queryParameters(reflect.TypeOf(route.Query))...)
+	}
+	if route.Body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: registry.schemaOf(reflect.TypeOf(route.Body))},
+			},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if route.Response != nil {
+		success.Content = map[string]MediaType{
+			"application/json": {Schema: registry.schemaOf(reflect.TypeOf(route.Response))},
+		}
+	}
+	operation.Responses[strconv.Itoa(status)] = success
+
+	errorStatuses := append([]int{http.StatusInternalServerError}, route.Errors...)
+	if len(pathParams) > 0 || route.Query != nil || route.Body != nil {
+		errorStatuses = append(errorStatuses, http.StatusBadRequest)
+	}
+	if len(pathParams) > 0 {
+		errorStatuses = append(errorStatuses, http.StatusNotFound)
+	}
+	for _, errorStatus := range errorStatuses {
+		operation.Responses[strconv.Itoa(errorStatus)] = &Response{
+			Description: http.StatusText(errorStatus),
+			Content: map[string]MediaType{
+				"application/json": {Schema: errorSchema},
+			},
+		}
+	}
+
+	return operation
+}
+
+// openApiPath converts fiber's :param segments to {param}.
+func openApiPath(fiberPath string) (string, []string) {
+	segments := strings.Split(fiberPath, "/")
+	params := make([]string, 0)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			name := strings.TrimPrefix(segment, ":")
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func operationId(route Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.FieldsFunc(route.Path, func(r rune) bool { return r == '/' || r == '-' }) {
+		segment = strings.TrimPrefix(segment, ":")
+		if segment == "api" || segment == "v1" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"ai-notetaking-be/pkg/apperror"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ErrorResponse struct {
+	Code    string                `json:"code"`
+	Message string                `json:"message"`
+	Details []apperror.FieldError `json:"details,omitempty"`
+}
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindNotFound:    fiber.StatusNotFound,
+	apperror.KindValidation:  fiber.StatusBadRequest,
+	apperror.KindConflict:    fiber.StatusConflict,
+	apperror.KindForbidden:   fiber.StatusForbidden,
+	apperror.KindUnavailable: fiber.StatusServiceUnavailable,
+}
+
+// ErrorHandler is the fiber.Config ErrorHandler, it gives every failed request
+// the same JSON body and hides internal errors from the client.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) && appErr.Kind != apperror.KindInternal {
+		if appErr.Err != nil {
+			slog.WarnContext(c.UserContext(), "Request failed", "method", c.Method(), "path", c.Path(), "error", err)
+		}
+		return c.Status(kindStatus[appErr.Kind]).JSON(ErrorResponse{
+			Code:    appErr.Kind.String(),
+			Message: appErr.Message,
+			Details: appErr.Fields,
+		})
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(ErrorResponse{
+			Code:    statusCode(fiberErr.Code),
+			Message: fiberErr.Message,
+		})
+	}
+
+	slog.ErrorContext(c.UserContext(), "Request failed", "method", c.Method(), "path", c.Path(), "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		Code:    apperror.KindInternal.String(),
+		Message: "internal server error",
+	})
+}
+
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
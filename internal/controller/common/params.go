@@ -0,0 +1,40 @@
+package common
+
+import (
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/validation"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func ParamUuid(c *fiber.Ctx, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		return uuid.Nil, apperror.Validation(fmt.Sprintf("%s must be a valid uuid", name))
+	}
+
+	return id, nil
+}
+
+// ParseBody and ParseQuery parse the request into a DTO and check its
+// `validate` tags. Malformed input is a validation error instead of the
+// internal error fiber's parsers return.
+func ParseBody(c *fiber.Ctx, out any) error {
+	err := c.BodyParser(out)
+	if err != nil {
+		return apperror.New(apperror.KindValidation, "request body is malformed", err)
+	}
+
+	return validation.Struct(out)
+}
+
+func ParseQuery(c *fiber.Ctx, out any) error {
+	err := c.QueryParser(out)
+	if err != nil {
+		return apperror.New(apperror.KindValidation, "query parameters are malformed", err)
+	}
+
+	return validation.Struct(out)
+}
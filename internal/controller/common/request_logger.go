@@ -0,0 +1,41 @@
+package common
+
+import (
+	"ai-notetaking-be/pkg/logging"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+// RequestLogger gives every request an id, taken from X-Request-Id when the
+// client sent one, and logs the request once it is answered. The id travels in
+// the user context, so everything logged or published while serving the
+// request carries it.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestId := c.Get(RequestIdHeader)
+		if requestId == "" {
+			requestId = uuid.NewString()
+		}
+		c.Set(RequestIdHeader, requestId)
+		c.SetUserContext(logging.WithRequestId(c.UserContext(), requestId))
+
+		start := time.Now()
+		status, err := HandleAndStatus(c)
+
+		slog.InfoContext(
+			c.UserContext(),
+			"Request",
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"duration", time.Since(start),
+		)
+
+		return err
+	}
+}
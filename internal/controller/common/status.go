@@ -0,0 +1,15 @@
+package common
+
+import "github.com/gofiber/fiber/v2"
+
+// HandleAndStatus runs the rest of the chain for middlewares that record the
+// response status. An error is handed to the error handler right away so the
+// status is the one the client gets, not the 200 fiber starts with.
+func HandleAndStatus(c *fiber.Ctx) (int, error) {
+	err := c.Next()
+	if err != nil {
+		err = c.App().ErrorHandler(c, err)
+	}
+
+	return c.Response().StatusCode(), err
+}
@@ -0,0 +1,47 @@
+package health
+
+import (
+	healthservice "ai-notetaking-be/internal/service/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type IHealthController interface {
+	Live(c *fiber.Ctx) error
+	Ready(c *fiber.Ctx) error
+	Status(c *fiber.Ctx) error
+}
+
+type healthController struct {
+	healthService healthservice.IHealthService
+}
+
+// Live only proves the process serves requests, it never looks at dependencies
+// so a broken database does not get every replica restarted.
+func (hc *healthController) Live(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(&healthservice.ReadinessResponse{
+		Status: healthservice.StatusUp,
+		Checks: make([]*healthservice.CheckResponse, 0),
+	})
+}
+
+func (hc *healthController) Ready(c *fiber.Ctx) error {
+	res := hc.healthService.Ready(c.UserContext())
+	if res.Status != healthservice.StatusUp {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(res)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (hc *healthController) Status(c *fiber.Ctx) error {
+	res := hc.healthService.Status(c.UserContext())
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func NewHealthController(healthService healthservice.IHealthService) IHealthController {
+	return &healthController{
+		healthService: healthService,
+	}
+}
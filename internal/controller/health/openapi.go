@@ -0,0 +1,32 @@
+package health
+
+import (
+	"ai-notetaking-be/internal/controller/apidoc"
+	healthservice "ai-notetaking-be/internal/service/health"
+	"net/http"
+)
+
+// ApiRoutes documents the routes assigned by AssignHealthRoutes.
+var ApiRoutes = []apidoc.Route{
+	{
+		Method:   http.MethodGet,
+		Path:     "/healthz",
+		Summary:  "Report that the process is alive",
+		Tag:      "health",
+		Response: healthservice.ReadinessResponse{},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/readyz",
+		Summary:  "Check the dependencies, answers 503 with the same body when one is down",
+		Tag:      "health",
+		Response: healthservice.ReadinessResponse{},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/status",
+		Summary:  "Show dependency checks, queue depths and the embedding backlog",
+		Tag:      "health",
+		Response: healthservice.StatusResponse{},
+	},
+}
@@ -0,0 +1,9 @@
+package health
+
+import "github.com/gofiber/fiber/v2"
+
+func AssignHealthRoutes(app *fiber.App, healthController IHealthController) {
+	app.Get("/healthz", healthController.Live)
+	app.Get("/readyz", healthController.Ready)
+	app.Get("/status", healthController.Status)
+}
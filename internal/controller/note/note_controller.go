@@ -1,16 +1,17 @@
 package note
 
 import (
+	"ai-notetaking-be/internal/controller/common"
 	noteservice "ai-notetaking-be/internal/service/note"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type INoteController interface {
 	Create(c *fiber.Ctx) error
 	Search(c *fiber.Ctx) error
 	Ask(c *fiber.Ctx) error
+	Related(c *fiber.Ctx) error
 	Update(c *fiber.Ctx) error
 	UpdateNotebook(c *fiber.Ctx) error
 	Delete(c *fiber.Ctx) error
@@ -23,7 +24,7 @@ type noteController struct {
 
 func (nc *noteController) Create(c *fiber.Ctx) error {
 	var request noteservice.CreateNoteRequest
-	err := c.BodyParser(&request)
+	err := common.ParseBody(c, &request)
 	if err != nil {
 		return err
 	}
@@ -38,7 +39,7 @@ func (nc *noteController) Create(c *fiber.Ctx) error {
 
 func (nc *noteController) Search(c *fiber.Ctx) error {
 	var request noteservice.SearchNoteRequest
-	err := c.QueryParser(&request)
+	err := common.ParseQuery(c, &request)
 	if err != nil {
 		return err
 	}
@@ -53,7 +54,7 @@ func (nc *noteController) Search(c *fiber.Ctx) error {
 
 func (nc *noteController) Ask(c *fiber.Ctx) error {
 	var request noteservice.AskNoteRequest
-	err := c.QueryParser(&request)
+	err := common.ParseQuery(c, &request)
 	if err != nil {
 		return err
 	}
@@ -66,12 +67,34 @@ func (nc *noteController) Ask(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
+func (nc *noteController) Related(c *fiber.Ctx) error {
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request noteservice.RelatedNoteRequest
+	err = common.ParseQuery(c, &request)
+	if err != nil {
+		return err
+	}
+
+	res, err := nc.noteService.Related(c.UserContext(), idUuid, &request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
 func (nc *noteController) Update(c *fiber.Ctx) error {
-	id := c.Params("id")
-	idUuid, _ := uuid.Parse(id)
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
 
 	var request noteservice.UpdateNoteRequest
-	err := c.BodyParser(&request)
+	err = common.ParseBody(c, &request)
 	if err != nil {
 		return err
 	}
@@ -85,11 +108,13 @@ func (nc *noteController) Update(c *fiber.Ctx) error {
 }
 
 func (nc *noteController) UpdateNotebook(c *fiber.Ctx) error {
-	id := c.Params("id")
-	idUuid, _ := uuid.Parse(id)
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
 
 	var request noteservice.UpdateNoteNotebookRequest
-	err := c.BodyParser(&request)
+	err = common.ParseBody(c, &request)
 	if err != nil {
 		return err
 	}
@@ -103,10 +128,12 @@ func (nc *noteController) UpdateNotebook(c *fiber.Ctx) error {
 }
 
 func (nc *noteController) Delete(c *fiber.Ctx) error {
-	id := c.Params("id")
-	idUuid, _ := uuid.Parse(id)
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
 
-	err := nc.noteService.Delete(c.UserContext(), idUuid)
+	err = nc.noteService.Delete(c.UserContext(), idUuid)
 	if err != nil {
 		return err
 	}
@@ -115,8 +142,10 @@ func (nc *noteController) Delete(c *fiber.Ctx) error {
 }
 
 func (nc *noteController) Show(c *fiber.Ctx) error {
-	id := c.Params("id")
-	idUuid, _ := uuid.Parse(id)
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
 
 	note, err := nc.noteService.Show(c.UserContext(), idUuid)
 	if err != nil {
@@ -1,10 +1,10 @@
 package note
 
 import (
+	"ai-notetaking-be/internal/controller/common"
 	noteservice "ai-notetaking-be/internal/service/note"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type INotebookController interface {
@@ -22,7 +22,7 @@ type notebookController struct {
 
 func (nc *notebookController) Create(c *fiber.Ctx) error {
 	var request noteservice.CreateNotebookRequest
-	err := c.BodyParser(&request)
+	err := common.ParseBody(c, &request)
 	if err != nil {
 		return err
 	}
@@ -37,12 +37,14 @@ func (nc *notebookController) Create(c *fiber.Ctx) error {
 
 func (nc *notebookController) Update(c *fiber.Ctx) error {
 	var request noteservice.UpdateNotebookRequest
-	err := c.BodyParser(&request)
+	err := common.ParseBody(c, &request)
+	if err != nil {
+		return err
+	}
+	idUuid, err := common.ParamUuid(c, "id")
 	if err != nil {
 		return err
 	}
-	id := c.Params("id")
-	idUuid := uuid.MustParse(id)
 
 	res, err := nc.notebookService.Update(c.UserContext(), idUuid, &request)
 	if err != nil {
@@ -54,12 +56,14 @@ func (nc *notebookController) Update(c *fiber.Ctx) error {
 
 func (nc *notebookController) UpdateParent(c *fiber.Ctx) error {
 	var request noteservice.UpdateNotebookParentRequest
-	err := c.BodyParser(&request)
+	err := common.ParseBody(c, &request)
+	if err != nil {
+		return err
+	}
+	idUuid, err := common.ParamUuid(c, "id")
 	if err != nil {
 		return err
 	}
-	id := c.Params("id")
-	idUuid := uuid.MustParse(id)
 
 	res, err := nc.notebookService.UpdateParent(c.UserContext(), idUuid, &request)
 	if err != nil {
@@ -70,10 +74,12 @@ func (nc *notebookController) UpdateParent(c *fiber.Ctx) error {
 }
 
 func (nc *notebookController) Delete(c *fiber.Ctx) error {
-	id := c.Params("id")
-	idUuid := uuid.MustParse(id)
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
 
-	err := nc.notebookService.Delete(c.UserContext(), idUuid)
+	err = nc.notebookService.Delete(c.UserContext(), idUuid)
 	if err != nil {
 		return err
 	}
@@ -82,8 +88,10 @@ func (nc *notebookController) Delete(c *fiber.Ctx) error {
 }
 
 func (nc *notebookController) Show(c *fiber.Ctx) error {
-	id := c.Params("id")
-	idUuid := uuid.MustParse(id)
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
 
 	res, err := nc.notebookService.Show(c.UserContext(), idUuid)
 	if err != nil {
@@ -0,0 +1,120 @@
+package note
+
+import (
+	"ai-notetaking-be/internal/controller/apidoc"
+	noteservice "ai-notetaking-be/internal/service/note"
+	"net/http"
+)
+
+// ApiRoutes documents the routes assigned by AssignNoteRoutes.
+var ApiRoutes = []apidoc.Route{
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/note",
+		Summary:  "Semantic search over notes",
+		Tag:      "note",
+		Query:    noteservice.SearchNoteRequest{},
+		Response: []*noteservice.SearchNoteResponse{},
+		Errors:   []int{http.StatusServiceUnavailable},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/note/ask",
+		Summary:  "Answer a question using the most similar notes",
+		Tag:      "note",
+		Query:    noteservice.AskNoteRequest{},
+		Response: noteservice.AskNoteResponse{},
+		Errors:   []int{http.StatusServiceUnavailable},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/note/:id",
+		Summary:  "Show a note",
+		Tag:      "note",
+		Response: noteservice.ShowNoteResponse{},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/note/:id/related",
+		Summary:  "Notes most similar to a note, most similar first",
+		Tag:      "note",
+		Query:    noteservice.RelatedNoteRequest{},
+		Response: []*noteservice.RelatedNoteResponse{},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/api/v1/note",
+		Summary:  "Create a note",
+		Tag:      "note",
+		Body:     noteservice.CreateNoteRequest{},
+		Response: noteservice.CreateNoteResponse{},
+		Status:   http.StatusCreated,
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/api/v1/note/:id",
+		Summary:  "Update the title and content of a note",
+		Tag:      "note",
+		Body:     noteservice.UpdateNoteRequest{},
+		Response: noteservice.UpdateNoteResponse{},
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/api/v1/note/:id/update-notebook",
+		Summary:  "Move a note to another notebook",
+		Tag:      "note",
+		Body:     noteservice.UpdateNoteNotebookRequest{},
+		Response: noteservice.UpdateNoteNotebookResponse{},
+	},
+	{
+		Method:  http.MethodDelete,
+		Path:    "/api/v1/note/:id",
+		Summary: "Delete a note",
+		Tag:     "note",
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/notebook",
+		Summary:  "List notebooks with their notes",
+		Tag:      "notebook",
+		Response: noteservice.GetAllNotebookResponse{},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/api/v1/notebook",
+		Summary:  "Create a notebook",
+		Tag:      "notebook",
+		Body:     noteservice.CreateNotebookRequest{},
+		Response: noteservice.CreateNotebookResponse{},
+		Status:   http.StatusCreated,
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/notebook/:id",
+		Summary:  "Show a notebook",
+		Tag:      "notebook",
+		Response: noteservice.ShowNotebookResponse{},
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/api/v1/notebook/:id",
+		Summary:  "Rename a notebook",
+		Tag:      "notebook",
+		Body:     noteservice.UpdateNotebookRequest{},
+		Response: noteservice.UpdateNotebookResponse{},
+	},
+	{
+		Method:   http.MethodPut,
+		Path:     "/api/v1/notebook/:id/update-parent",
+		Summary:  "Move a notebook under another notebook",
+		Tag:      "notebook",
+		Body:     noteservice.UpdateNotebookParentRequest{},
+		Response: noteservice.UpdateNotebookParentResponse{},
+	},
+	{
+		Method:  http.MethodDelete,
+		Path:    "/api/v1/notebook/:id",
+		Summary: "Delete a notebook with its notes",
+		Tag:     "notebook",
+	},
+}
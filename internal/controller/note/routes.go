@@ -5,8 +5,9 @@ import "github.com/gofiber/fiber/v2"
 func AssignNoteRoutes(app *fiber.App, noteController INoteController, notebookController INotebookController) {
 	group := app.Group("/api/v1/note")
 	group.Get("", noteController.Search)
-	group.Get(":id", noteController.Show)
 	group.Get("ask", noteController.Ask)
+	group.Get(":id", noteController.Show)
+	group.Get(":id/related", noteController.Related)
 	group.Post("", noteController.Create)
 	group.Put(":id", noteController.Update)
 	group.Put(":id/update-notebook", noteController.UpdateNotebook)
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"ai-notetaking-be/internal/controller/apidoc"
+	webhookservice "ai-notetaking-be/internal/service/webhook"
+	"net/http"
+)
+
+// ApiRoutes documents the routes assigned by AssignWebhookRoutes.
+var ApiRoutes = []apidoc.Route{
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/webhook",
+		Summary:  "List webhook subscriptions",
+		Tag:      "webhook",
+		Response: []*webhookservice.ShowWebhookSubscriptionResponse{},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/api/v1/webhook",
+		Summary:  "Subscribe a url to events",
+		Tag:      "webhook",
+		Body:     webhookservice.CreateWebhookSubscriptionRequest{},
+		Response: webhookservice.CreateWebhookSubscriptionResponse{},
+		Status:   http.StatusCreated,
+	},
+	{
+		Method:  http.MethodDelete,
+		Path:    "/api/v1/webhook/:id",
+		Summary: "Delete a webhook subscription",
+		Tag:     "webhook",
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/api/v1/webhook/:id/deliveries",
+		Summary:  "List the latest deliveries of a subscription",
+		Tag:      "webhook",
+		Response: []*webhookservice.ShowWebhookDeliveryResponse{},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/api/v1/webhook/delivery/:id/redeliver",
+		Summary:  "Schedule a delivery to be sent again right away",
+		Tag:      "webhook",
+		Response: webhookservice.ShowWebhookDeliveryResponse{},
+	},
+}
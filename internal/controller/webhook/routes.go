@@ -0,0 +1,12 @@
+package webhook
+
+import "github.com/gofiber/fiber/v2"
+
+func AssignWebhookRoutes(app *fiber.App, webhookController IWebhookController) {
+	group := app.Group("/api/v1/webhook")
+	group.Get("", webhookController.GetAll)
+	group.Post("", webhookController.Create)
+	group.Delete(":id", webhookController.Delete)
+	group.Get(":id/deliveries", webhookController.GetDeliveries)
+	group.Post("delivery/:id/redeliver", webhookController.Redeliver)
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"ai-notetaking-be/internal/controller/common"
+	webhookservice "ai-notetaking-be/internal/service/webhook"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type IWebhookController interface {
+	Create(c *fiber.Ctx) error
+	GetAll(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+	GetDeliveries(c *fiber.Ctx) error
+	Redeliver(c *fiber.Ctx) error
+}
+
+type webhookController struct {
+	webhookService webhookservice.IWebhookService
+}
+
+func (wc *webhookController) Create(c *fiber.Ctx) error {
+	var request webhookservice.CreateWebhookSubscriptionRequest
+	err := common.ParseBody(c, &request)
+	if err != nil {
+		return err
+	}
+
+	res, err := wc.webhookService.Create(c.UserContext(), &request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (wc *webhookController) GetAll(c *fiber.Ctx) error {
+	res, err := wc.webhookService.GetAll(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (wc *webhookController) Delete(c *fiber.Ctx) error {
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
+
+	err = wc.webhookService.Delete(c.UserContext(), idUuid)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (wc *webhookController) GetDeliveries(c *fiber.Ctx) error {
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
+
+	res, err := wc.webhookService.GetDeliveries(c.UserContext(), idUuid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (wc *webhookController) Redeliver(c *fiber.Ctx) error {
+	idUuid, err := common.ParamUuid(c, "id")
+	if err != nil {
+		return err
+	}
+
+	res, err := wc.webhookService.Redeliver(c.UserContext(), idUuid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func NewWebhookController(webhookService webhookservice.IWebhookService) IWebhookController {
+	return &webhookController{
+		webhookService: webhookService,
+	}
+}
@@ -10,6 +10,7 @@ type NoteEmbedding struct {
 	Id           uuid.UUID
 	OriginalText string
 	NoteId       uuid.UUID
+	Model        string
 	Embedding    []float32
 	CreatedAt    time.Time
 	CreatedBy    string
@@ -0,0 +1,129 @@
+package event
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is bumped whenever the data of an existing event type changes
+// in a way older consumers can not read.
+const SchemaVersion = 1
+
+const (
+	TypeNoteCreated     = "note.created"
+	TypeNoteUpdated     = "note.updated"
+	TypeNoteMoved       = "note.moved"
+	TypeNoteDeleted     = "note.deleted"
+	TypeNotebookRenamed = "notebook.renamed"
+	TypeNotebookMoved   = "notebook.moved"
+	TypeNotebookDeleted = "notebook.deleted"
+)
+
+var AllTypes = []string{
+	TypeNoteCreated,
+	TypeNoteUpdated,
+	TypeNoteMoved,
+	TypeNoteDeleted,
+	TypeNotebookRenamed,
+	TypeNotebookMoved,
+	TypeNotebookDeleted,
+}
+
+// Priority decides which lane an event travels in, so a bulk reindex never
+// delays the embedding of a note a user is editing right now.
+const (
+	PriorityInteractive = "interactive"
+	PriorityBulk        = "bulk"
+)
+
+// bulkTypes fan out to every note of a notebook.
+var bulkTypes = map[string]bool{
+	TypeNotebookRenamed: true,
+	TypeNotebookMoved:   true,
+}
+
+func PriorityOf(eventType string) string {
+	if bulkTypes[eventType] {
+		return PriorityBulk
+	}
+
+	return PriorityInteractive
+}
+
+type Envelope struct {
+	Id            uuid.UUID `json:"id"`
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	Actor         string    `json:"actor"`
+	Priority      string    `json:"priority,omitempty"`
+	// RequestId correlates the event with the HTTP request that caused it.
+	RequestId string `json:"request_id,omitempty"`
+	// TraceContext is the W3C trace context of the publisher, for transports
+	// without message metadata.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	Data         json.RawMessage   `json:"data"`
+}
+
+func NewEnvelope(eventType string, actor string, data any) (*Envelope, error) {
+	dataJson, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Id:            uuid.New(),
+		Type:          eventType,
+		SchemaVersion: SchemaVersion,
+		OccurredAt:    time.Now(),
+		Actor:         actor,
+		Priority:      PriorityOf(eventType),
+		Data:          dataJson,
+	}, nil
+}
+
+// IsBulk treats envelopes published before priorities existed as interactive.
+func (e *Envelope) IsBulk() bool {
+	return e.Priority == PriorityBulk
+}
+
+func (e *Envelope) DecodeData(dest any) error {
+	return json.Unmarshal(e.Data, dest)
+}
+
+type NoteCreated struct {
+	NoteId     uuid.UUID  `json:"note_id"`
+	NotebookId *uuid.UUID `json:"notebook_id"`
+}
+
+type NoteUpdated struct {
+	NoteId uuid.UUID `json:"note_id"`
+}
+
+type NoteMoved struct {
+	NoteId         uuid.UUID  `json:"note_id"`
+	FromNotebookId *uuid.UUID `json:"from_notebook_id"`
+	ToNotebookId   *uuid.UUID `json:"to_notebook_id"`
+}
+
+type NoteDeleted struct {
+	NoteId uuid.UUID `json:"note_id"`
+}
+
+type NotebookRenamed struct {
+	NotebookId uuid.UUID `json:"notebook_id"`
+	OldName    string    `json:"old_name"`
+	NewName    string    `json:"new_name"`
+}
+
+type NotebookMoved struct {
+	NotebookId   uuid.UUID  `json:"notebook_id"`
+	FromParentId *uuid.UUID `json:"from_parent_id"`
+	ToParentId   *uuid.UUID `json:"to_parent_id"`
+}
+
+type NotebookDeleted struct {
+	NotebookId uuid.UUID `json:"notebook_id"`
+}
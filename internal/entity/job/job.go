@@ -0,0 +1,25 @@
+package job
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusFailed     = "failed"
+)
+
+type Job struct {
+	Id          uuid.UUID
+	QueueName   string
+	Payload     []byte
+	Status      string
+	Attempts    int
+	LastError   *string
+	AvailableAt time.Time
+	LockedAt    *time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+type Subscription struct {
+	Id         uuid.UUID
+	Url        string
+	Secret     string
+	EventTypes []string
+	IsActive   bool
+	CreatedAt  time.Time
+	CreatedBy  string
+	UpdatedAt  *time.Time
+	UpdatedBy  *string
+	DeletedAt  *time.Time
+	DeletedBy  *string
+	IsDeleted  bool
+}
+
+type Delivery struct {
+	Id             uuid.UUID
+	SubscriptionId uuid.UUID
+	EventId        uuid.UUID
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	ResponseStatus *int
+	ResponseBody   *string
+	LastError      *string
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"ai-notetaking-be/internal/controller/common"
+	"ai-notetaking-be/internal/service/consumer"
+	noteservice "ai-notetaking-be/internal/service/note"
+	publisherservice "ai-notetaking-be/internal/service/publisher"
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/gemini"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "notetaking"
+
+// Metrics owns a registry of its own so only what the service records, plus
+// the Go runtime and process collectors, ends up on /metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration *prometheus.HistogramVec
+
+	publishTotal    *prometheus.CounterVec
+	publishDuration *prometheus.HistogramVec
+	consumeTotal    *prometheus.CounterVec
+	consumeDuration *prometheus.HistogramVec
+
+	embeddingDuration *prometheus.HistogramVec
+	embeddingErrors   *prometheus.CounterVec
+	queryCacheTotal   *prometheus.CounterVec
+
+	vectorSearchDuration *prometheus.HistogramVec
+
+	chatDuration      *prometheus.HistogramVec
+	chatTokens        *prometheus.CounterVec
+	chatPhaseDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		publishTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queue_published_total",
+			Help:      "Messages published by queue and result.",
+		}, []string{"queue", "result"}),
+		publishDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "queue_publish_duration_seconds",
+			Help:      "Duration of publishing a message by queue.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"queue"}),
+		consumeTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queue_consumed_total",
+			Help:      "Messages consumed by result.",
+		}, []string{"result"}),
+		consumeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "queue_consume_duration_seconds",
+			Help:      "Duration of handling a consumed message, retries included.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"result"}),
+		embeddingDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "embedding_request_duration_seconds",
+			Help:      "Duration of embedding provider calls by provider.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"provider"}),
+		embeddingErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "embedding_errors_total",
+			Help:      "Failed embedding provider calls by provider and error type.",
+		}, []string{"provider", "error_type"}),
+		queryCacheTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "embedding_query_cache_lookups_total",
+			Help:      "Query embedding cache lookups by tier (memory or store) and result (hit, miss or error).",
+		}, []string{"tier", "result"}),
+		vectorSearchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "vector_search_duration_seconds",
+			Help:      "Duration of nearest neighbour queries on note embeddings.",
+			Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}, []string{"result"}),
+		chatDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "chat_request_duration_seconds",
+			Help:      "Duration of chat model calls by model and result.",
+			Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+		}, []string{"model", "result"}),
+		chatTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chat_tokens_total",
+			Help:      "Tokens processed by the chat model, kind is prompt or eval.",
+		}, []string{"model", "kind"}),
+		chatPhaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "chat_phase_duration_seconds",
+			Help:      "Time the chat model spent loading, evaluating the prompt and generating.",
+			Buckets:   []float64{0.05, 0.1, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+		}, []string{"model", "phase"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestDuration,
+		m.publishTotal,
+		m.publishDuration,
+		m.consumeTotal,
+		m.consumeDuration,
+		m.embeddingDuration,
+		m.embeddingErrors,
+		m.queryCacheTotal,
+		m.vectorSearchDuration,
+		m.chatDuration,
+		m.chatTokens,
+		m.chatPhaseDuration,
+	)
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// HttpMiddleware times every request under its route pattern rather than its
+// path, so note ids don't blow up the label cardinality.
+func (m *Metrics) HttpMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		status, err := common.HandleAndStatus(c)
+
+		m.httpRequestDuration.WithLabelValues(
+			c.Method(),
+			c.Route().Path,
+			strconv.Itoa(status),
+		).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// ObservePublish matches publisherservice.PublishObserveFunc, wire it with
+// publisherservice.NewObservedPublisherService.
+func (m *Metrics) ObservePublish(queue string) publisherservice.PublishObserveFunc {
+	return func(err error, latency time.Duration) {
+		m.publishTotal.WithLabelValues(queue, result(err)).Inc()
+		m.publishDuration.WithLabelValues(queue).Observe(latency.Seconds())
+	}
+}
+
+// ObserveMessage implements consumer.IMessageMetricsRecorder.
+func (m *Metrics) ObserveMessage(result string, duration time.Duration) {
+	m.consumeTotal.WithLabelValues(result).Inc()
+	m.consumeDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// ObserveLimiter exports the in flight count and the current limit of the
+// limiter guarding the given lane.
+func (m *Metrics) ObserveLimiter(lane string, limiter *consumer.ConcurrencyLimiter) {
+	labels := prometheus.Labels{"lane": lane}
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "consumer_in_flight",
+			Help:        "Messages currently being handled.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(limiter.InFlight())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "consumer_concurrency_limit",
+			Help:        "Messages allowed to be handled at once.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(limiter.Limit())
+		}),
+	)
+}
+
+// ObserveEmbedding matches embedding.ObserveFunc, wire it with embedding.NewObservedProvider.
+func (m *Metrics) ObserveEmbedding(provider string) embedding.ObserveFunc {
+	return func(err error, latency time.Duration) {
+		m.embeddingDuration.WithLabelValues(provider).Observe(latency.Seconds())
+		if err != nil {
+			m.embeddingErrors.WithLabelValues(provider, embedErrorType(err)).Inc()
+		}
+	}
+}
+
+// ObserveQueryCache matches embedding.CacheObserveFunc, wire it with embedding.NewCachedProvider.
+func (m *Metrics) ObserveQueryCache(tier string, result string) {
+	m.queryCacheTotal.WithLabelValues(tier, result).Inc()
+}
+
+// ObserveVectorSearch implements noteservice.INoteMetricsRecorder.
+func (m *Metrics) ObserveVectorSearch(err error, latency time.Duration) {
+	m.vectorSearchDuration.WithLabelValues(result(err)).Observe(latency.Seconds())
+}
+
+// ObserveChat implements noteservice.INoteMetricsRecorder. Ollama reports its
+// durations in nanoseconds.
+func (m *Metrics) ObserveChat(model string, response *noteservice.ChatResponse, err error, latency time.Duration) {
+	m.chatDuration.WithLabelValues(model, result(err)).Observe(latency.Seconds())
+	if response == nil {
+		return
+	}
+
+	m.chatTokens.WithLabelValues(model, "prompt").Add(float64(response.PromptEvalCount))
+	m.chatTokens.WithLabelValues(model, "eval").Add(float64(response.EvalCount))
+	m.chatPhaseDuration.WithLabelValues(model, "load").Observe(time.Duration(response.LoadDuration).Seconds())
+	m.chatPhaseDuration.WithLabelValues(model, "prompt_eval").Observe(time.Duration(response.PromptEvalDuration).Seconds())
+	m.chatPhaseDuration.WithLabelValues(model, "eval").Observe(time.Duration(response.EvalDuration).Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "success"
+}
+
+func embedErrorType(err error) string {
+	var embedErr *gemini.EmbedError
+	if errors.As(err, &embedErr) {
+		return embedErr.Type.String()
+	}
+
+	return gemini.ErrTypeUnknown.String()
+}
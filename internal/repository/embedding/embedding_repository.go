@@ -4,6 +4,9 @@ import (
 	embeddingentity "ai-notetaking-be/internal/entity/embedding"
 	"ai-notetaking-be/pkg/database"
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,30 +17,106 @@ import (
 type IEmbeddingRepository interface {
 	UsingTx(ctx context.Context, tx database.DatabaseQueryer) IEmbeddingRepository
 	CreateNoteEmbedding(ctx context.Context, noteEmbedding *embeddingentity.NoteEmbedding) error
+	GetByNoteId(ctx context.Context, noteId uuid.UUID) ([]embeddingentity.NoteEmbedding, error)
 	FindMostSimilarNoteIds(ctx context.Context, embeddingValue []float32) ([]uuid.UUID, error)
-	DeleteNoteEmbeddings(ctx context.Context, noteId uuid.UUID, deletedBy string) error
+	SearchNearest(ctx context.Context, embeddingValue []float32, options SearchOptions) ([]SimilarNote, error)
+	LockNote(ctx context.Context, noteId uuid.UUID) error
+	DeleteNoteEmbeddings(ctx context.Context, noteId uuid.UUID, model string, deletedBy string) error
 	DeleteByNoteId(ctx context.Context, noteId uuid.UUID, deletedBy string) error
 	DeleteByNotebookId(ctx context.Context, notebookId uuid.UUID, deletedBy string) error
+	CountStaleNotes(ctx context.Context, model string) (int, error)
+}
+
+// SearchOptions tune a nearest neighbour search, zero values fall back to the
+// defaults of the repository.
+type SearchOptions struct {
+	Limit int
+	// EfSearch is the size of the HNSW candidate list, larger finds more of the
+	// true neighbours at the cost of latency. It caps the rows an HNSW search
+	// returns, so it is raised to at least Limit, and to filteredEfSearchFactor
+	// times Limit for a filtered search.
+	EfSearch int
+	// Probes is the number of IVFFlat lists visited.
+	Probes int
+	// Exact skips the vector index and compares every row.
+	Exact bool
+	// ExcludeNoteId, NotebookId and Model narrow the searched embeddings. An
+	// approximate index filters the candidates it found, so a narrow filter
+	// can still return fewer than Limit notes.
+	ExcludeNoteId *uuid.UUID
+	NotebookId    *uuid.UUID
+	Model         string
+}
+
+const (
+	defaultSearchLimit = 10
+	// pgvector searches with an ef_search of 40 unless told otherwise and
+	// accepts at most 1000.
+	pgvectorDefaultEfSearch = 40
+	maxEfSearch             = 1000
+	// filteredEfSearchFactor leaves room for the candidates a filter drops.
+	filteredEfSearchFactor = 4
+)
+
+func (o SearchOptions) withDefaults(defaults SearchOptions) SearchOptions {
+	if o.Limit <= 0 {
+		o.Limit = defaults.Limit
+	}
+	if o.Limit <= 0 {
+		o.Limit = defaultSearchLimit
+	}
+	if o.EfSearch <= 0 {
+		o.EfSearch = defaults.EfSearch
+	}
+	minEfSearch := o.Limit
+	if o.ExcludeNoteId != nil || o.NotebookId != nil || o.Model != "" {
+		minEfSearch *= filteredEfSearchFactor
+	}
+	minEfSearch = min(minEfSearch, maxEfSearch)
+	efSearch := o.EfSearch
+	if efSearch <= 0 {
+		efSearch = pgvectorDefaultEfSearch
+	}
+	if efSearch < minEfSearch {
+		o.EfSearch = minEfSearch
+	}
+	if o.Probes <= 0 {
+		o.Probes = defaults.Probes
+	}
+
+	return o
+}
+
+type SimilarNote struct {
+	NoteId   uuid.UUID
+	Distance float64
+	// Similarity is Distance as DistanceMetric.Similarity scores it.
+	Similarity float64
 }
 
 type embeddingRepository struct {
-	db database.DatabaseQueryer
+	db      database.DatabaseQueryer
+	metric  DistanceMetric
+	options SearchOptions
 }
 
 func (n *embeddingRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) IEmbeddingRepository {
 	return &embeddingRepository{
-		db: tx,
+		db:      tx,
+		metric:  n.metric,
+		options: n.options,
 	}
 }
 
 func (n *embeddingRepository) CreateNoteEmbedding(ctx context.Context, noteEmbedding *embeddingentity.NoteEmbedding) error {
 	_, err := n.db.Exec(
 		ctx,
-		"INSERT INTO embedding_notes (id, original_text, embedding, note_id, created_at, created_by) VALUES ($1, $2, $3, $4, $5, $6)",
+		"INSERT INTO embedding_notes (id, original_text, embedding, note_id, model, created_at, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7)",
 		noteEmbedding.Id,
 		noteEmbedding.OriginalText,
 		pgvector.NewVector(noteEmbedding.Embedding),
 		noteEmbedding.NoteId,
+		noteEmbedding.Model,
 		noteEmbedding.CreatedAt,
 		noteEmbedding.CreatedBy,
 	)
@@ -48,13 +127,28 @@ func (n *embeddingRepository) CreateNoteEmbedding(ctx context.Context, noteEmbed
 	return nil
 }
 
-func (n *embeddingRepository) DeleteNoteEmbeddings(ctx context.Context, noteId uuid.UUID, deletedBy string) error {
+// LockNote serializes embedding writes of a note until the surrounding transaction ends.
+func (n *embeddingRepository) LockNote(ctx context.Context, noteId uuid.UUID) error {
 	_, err := n.db.Exec(
 		ctx,
-		"UPDATE embedding_notes SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE note_id = $3",
+		"SELECT pg_advisory_xact_lock(hashtext($1))",
+		noteId.String(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *embeddingRepository) DeleteNoteEmbeddings(ctx context.Context, noteId uuid.UUID, model string, deletedBy string) error {
+	_, err := n.db.Exec(
+		ctx,
+		"UPDATE embedding_notes SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE note_id = $3 AND model = $4 AND is_deleted = false",
 		time.Now(),
 		deletedBy,
 		noteId,
+		model,
 	)
 	if err != nil {
 		return err
@@ -63,32 +157,143 @@ func (n *embeddingRepository) DeleteNoteEmbeddings(ctx context.Context, noteId u
 	return nil
 }
 
-func (n *embeddingRepository) FindMostSimilarNoteIds(ctx context.Context, embeddingValue []float32) ([]uuid.UUID, error) {
+// GetByNoteId returns the live embeddings of a note, one per model, newest
+// first.
+func (n *embeddingRepository) GetByNoteId(ctx context.Context, noteId uuid.UUID) ([]embeddingentity.NoteEmbedding, error) {
 	rows, err := n.db.Query(
 		ctx,
-		"SELECT note_id, original_text, embedding <-> $1 AS similarity FROM embedding_notes WHERE is_deleted = false ORDER BY similarity LIMIT 10",
-		pgvector.NewVector(embeddingValue),
+		"SELECT id, original_text, note_id, model, embedding::text, created_at, created_by FROM embedding_notes WHERE note_id = $1 AND is_deleted = false ORDER BY created_at DESC",
+		noteId,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	result := make([]uuid.UUID, 10)
-	i := 9
+	embeddings := make([]embeddingentity.NoteEmbedding, 0)
 	for rows.Next() {
-		var id uuid.UUID
-		var distance float32
+		var embedding embeddingentity.NoteEmbedding
+		var text string
+		err = rows.Scan(
+			&embedding.Id,
+			&embedding.OriginalText,
+			&embedding.NoteId,
+			&embedding.Model,
+			&text,
+			&embedding.CreatedAt,
+			&embedding.CreatedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		var vector pgvector.Vector
+		err = vector.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		embedding.Embedding = vector.Slice()
+		embeddings = append(embeddings, embedding)
+	}
 
-		err = rows.Scan(&id, &distance)
+	return embeddings, rows.Err()
+}
+
+// FindMostSimilarNoteIds returns the notes of the closest embeddings, closest
+// first, with the default search options.
+func (n *embeddingRepository) FindMostSimilarNoteIds(ctx context.Context, embeddingValue []float32) ([]uuid.UUID, error) {
+	notes, err := n.SearchNearest(ctx, embeddingValue, SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.NoteId)
+	}
+
+	return ids, nil
+}
+
+// SearchNearest orders the live embeddings by their distance to
+// embeddingValue. Index settings are applied with set_config(..., true), which
+// only lasts for the transaction the search runs in.
+func (n *embeddingRepository) SearchNearest(ctx context.Context, embeddingValue []float32, options SearchOptions) ([]SimilarNote, error) {
+	options = options.withDefaults(n.options)
+	settings := make(map[string]string)
+	if options.EfSearch > 0 {
+		settings["hnsw.ef_search"] = strconv.Itoa(options.EfSearch)
+	}
+	if options.Probes > 0 {
+		settings["ivfflat.probes"] = strconv.Itoa(options.Probes)
+	}
+	if options.Exact {
+		settings["enable_indexscan"] = "off"
+	}
+	args := []any{pgvector.NewVector(embeddingValue), options.Limit}
+	join := ""
+	conditions := []string{"e.is_deleted = false"}
+	if options.ExcludeNoteId != nil {
+		args = append(args, *options.ExcludeNoteId)
+		conditions = append(conditions, fmt.Sprintf("e.note_id <> $%d", len(args)))
+	}
+	if options.Model != "" {
+		args = append(args, options.Model)
+		conditions = append(conditions, fmt.Sprintf("e.model = $%d", len(args)))
+	}
+	if options.NotebookId != nil {
+		join = " JOIN notes n ON n.id = e.note_id"
+		args = append(args, *options.NotebookId)
+		conditions = append(conditions, fmt.Sprintf("n.notebook_id = $%d", len(args)))
+	}
+	query := fmt.Sprintf(
+		"SELECT e.note_id, e.embedding %s $1 AS distance FROM embedding_notes e%s WHERE %s ORDER BY distance LIMIT $2",
+		n.metric.Operator(),
+		join,
+		strings.Join(conditions, " AND "),
+	)
+
+	search := func(db database.DatabaseQueryer) ([]SimilarNote, error) {
+		for name, value := range settings {
+			_, err := db.Exec(ctx, "SELECT set_config($1, $2, true)", name, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		rows, err := db.Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
+		defer rows.Close()
 
-		result[i] = id
-		i--
+		result := make([]SimilarNote, 0, options.Limit)
+		for rows.Next() {
+			var note SimilarNote
+			err = rows.Scan(&note.NoteId, &note.Distance)
+			if err != nil {
+				return nil, err
+			}
+			note.Similarity = n.metric.Similarity(note.Distance)
+			result = append(result, note)
+		}
+
+		return result, rows.Err()
+	}
+	if len(settings) == 0 {
+		return search(n.db)
+	}
+
+	tx, err := n.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	result, err := search(tx)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return result, tx.Commit(ctx)
 }
 
 func (n *embeddingRepository) DeleteByNoteId(ctx context.Context, noteId uuid.UUID, deletedBy string) error {
@@ -121,8 +326,36 @@ func (n *embeddingRepository) DeleteByNotebookId(ctx context.Context, notebookId
 	return nil
 }
 
-func NewEmbeddingRepository(db *pgxpool.Pool) IEmbeddingRepository {
+// CountStaleNotes counts the live notes without an embedding of model made
+// after their last change, i.e. the notes still waiting to be embedded.
+func (n *embeddingRepository) CountStaleNotes(ctx context.Context, model string) (int, error) {
+	var count int
+	err := n.db.QueryRow(
+		ctx,
+		`
+			SELECT COUNT(*) FROM notes n
+			WHERE n.is_deleted = false
+				AND NOT EXISTS (
+					SELECT 1 FROM embedding_notes e
+					WHERE e.note_id = n.id
+						AND e.model = $1
+						AND e.is_deleted = false
+						AND e.created_at >= COALESCE(n.updated_at, n.created_at)
+				)
+		`,
+		model,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func NewEmbeddingRepository(db *pgxpool.Pool, metric DistanceMetric, options SearchOptions) IEmbeddingRepository {
 	return &embeddingRepository{
-		db: db,
+		db:      db,
+		metric:  metric,
+		options: options,
 	}
 }
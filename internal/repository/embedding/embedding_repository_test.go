@@ -0,0 +1,35 @@
+package embedding_test
+
+import (
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchOptionsRaiseEfSearchToLimit(t *testing.T) {
+	noteId := uuid.New()
+	cases := []struct {
+		name     string
+		options  embeddingrepository.SearchOptions
+		defaults embeddingrepository.SearchOptions
+		want     int
+	}{
+		{"server default covers the limit", embeddingrepository.SearchOptions{Limit: 10}, embeddingrepository.SearchOptions{}, 0},
+		{"limit above the server default", embeddingrepository.SearchOptions{Limit: 50}, embeddingrepository.SearchOptions{}, 50},
+		{"configured below the limit", embeddingrepository.SearchOptions{Limit: 30}, embeddingrepository.SearchOptions{EfSearch: 20}, 30},
+		{"configured above the limit", embeddingrepository.SearchOptions{Limit: 30}, embeddingrepository.SearchOptions{EfSearch: 100}, 100},
+		{"filtered search", embeddingrepository.SearchOptions{Limit: 50, ExcludeNoteId: &noteId}, embeddingrepository.SearchOptions{EfSearch: 64}, 200},
+		{"filtered search within the server default", embeddingrepository.SearchOptions{Limit: 5, Model: "model"}, embeddingrepository.SearchOptions{}, 0},
+		{"capped at the pgvector maximum", embeddingrepository.SearchOptions{Limit: 500, NotebookId: &noteId}, embeddingrepository.SearchOptions{}, 1000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.options.WithDefaults(c.defaults).EfSearch
+			if got != c.want {
+				t.Fatalf("EfSearch = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
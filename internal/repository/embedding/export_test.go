@@ -0,0 +1,7 @@
+package embedding
+
+var IvfflatLists = ivfflatLists
+
+func (o SearchOptions) WithDefaults(defaults SearchOptions) SearchOptions {
+	return o.withDefaults(defaults)
+}
@@ -0,0 +1,64 @@
+package embedding
+
+import "fmt"
+
+// DistanceMetric decides how embeddings are compared. Searches only use a
+// vector index built with the operator class of the same metric.
+type DistanceMetric string
+
+const (
+	MetricCosine       DistanceMetric = "cosine"
+	MetricInnerProduct DistanceMetric = "inner_product"
+	MetricL2           DistanceMetric = "l2"
+)
+
+var Metrics = []DistanceMetric{MetricCosine, MetricInnerProduct, MetricL2}
+
+func ParseDistanceMetric(s string) (DistanceMetric, error) {
+	for _, metric := range Metrics {
+		if string(metric) == s {
+			return metric, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown distance metric %q, expected one of %v", s, Metrics)
+}
+
+// Operator is the pgvector distance operator, smaller is closer for all of
+// them, <#> being the negative inner product.
+func (m DistanceMetric) Operator() string {
+	switch m {
+	case MetricInnerProduct:
+		return "<#>"
+	case MetricL2:
+		return "<->"
+	}
+
+	return "<=>"
+}
+
+// OperatorClass is what an index has to be built with to serve Operator.
+func (m DistanceMetric) OperatorClass() string {
+	switch m {
+	case MetricInnerProduct:
+		return "vector_ip_ops"
+	case MetricL2:
+		return "vector_l2_ops"
+	}
+
+	return "vector_cosine_ops"
+}
+
+// Similarity turns a distance of Operator into a score where larger is more
+// similar: the cosine similarity, the inner product, or 1 / (1 + distance)
+// for l2.
+func (m DistanceMetric) Similarity(distance float64) float64 {
+	switch m {
+	case MetricInnerProduct:
+		return -distance
+	case MetricL2:
+		return 1 / (1 + distance)
+	}
+
+	return 1 - distance
+}
@@ -0,0 +1,59 @@
+package embedding_test
+
+import (
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"math"
+	"testing"
+)
+
+func TestDistanceMetricSimilarity(t *testing.T) {
+	cases := []struct {
+		name     string
+		metric   embeddingrepository.DistanceMetric
+		distance float64
+		want     float64
+	}{
+		{"cosine identical", embeddingrepository.MetricCosine, 0, 1},
+		{"cosine orthogonal", embeddingrepository.MetricCosine, 1, 0},
+		{"cosine opposite", embeddingrepository.MetricCosine, 2, -1},
+		{"inner product is negated", embeddingrepository.MetricInnerProduct, -0.75, 0.75},
+		{"inner product negative", embeddingrepository.MetricInnerProduct, 0.5, -0.5},
+		{"l2 identical", embeddingrepository.MetricL2, 0, 1},
+		{"l2 distance one", embeddingrepository.MetricL2, 1, 0.5},
+		{"l2 far", embeddingrepository.MetricL2, 9, 0.1},
+		{"unknown falls back to cosine", embeddingrepository.DistanceMetric("dot"), 0.25, 0.75},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.metric.Similarity(c.distance)
+			if math.Abs(got-c.want) > 1e-9 {
+				t.Fatalf("Similarity(%v) = %v, want %v", c.distance, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIvfflatLists(t *testing.T) {
+	cases := []struct {
+		name string
+		rows int
+		want int
+	}{
+		{"empty table", 0, 1},
+		{"below a thousand rows", 999, 1},
+		{"thousand rows", 1000, 1},
+		{"rows per thousand", 250_000, 250},
+		{"a million rows", 1_000_000, 1000},
+		{"square root above a million", 4_000_000, 2000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := embeddingrepository.IvfflatLists(c.rows)
+			if got != c.want {
+				t.Fatalf("ivfflatLists(%d) = %d, want %d", c.rows, got, c.want)
+			}
+		})
+	}
+}
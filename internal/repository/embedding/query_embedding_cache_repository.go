@@ -0,0 +1,80 @@
+package embedding
+
+import (
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	pgvector "github.com/pgvector/pgvector-go"
+)
+
+// IQueryEmbeddingCacheRepository is the postgres tier of
+// embedding.NewCachedProvider, keys come from embedding.CacheKey.
+type IQueryEmbeddingCacheRepository interface {
+	Get(ctx context.Context, key string) ([]float32, bool, error)
+	Set(ctx context.Context, key string, embedding []float32, expiresAt time.Time) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type queryEmbeddingCacheRepository struct {
+	db database.DatabaseQueryer
+}
+
+func (q *queryEmbeddingCacheRepository) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	var text string
+	err := q.db.QueryRow(
+		ctx,
+		"SELECT embedding::text FROM query_embedding_cache WHERE key = $1 AND expires_at > $2",
+		key,
+		time.Now(),
+	).Scan(&text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var vector pgvector.Vector
+	err = vector.Parse(text)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return vector.Slice(), true, nil
+}
+
+func (q *queryEmbeddingCacheRepository) Set(ctx context.Context, key string, embedding []float32, expiresAt time.Time) error {
+	_, err := q.db.Exec(
+		ctx,
+		`
+			INSERT INTO query_embedding_cache (key, embedding, created_at, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key) DO UPDATE SET embedding = EXCLUDED.embedding, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		`,
+		key,
+		pgvector.NewVector(embedding),
+		time.Now(),
+		expiresAt,
+	)
+
+	return err
+}
+
+func (q *queryEmbeddingCacheRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := q.db.Exec(ctx, "DELETE FROM query_embedding_cache WHERE expires_at <= $1", time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func NewQueryEmbeddingCacheRepository(db *pgxpool.Pool) IQueryEmbeddingCacheRepository {
+	return &queryEmbeddingCacheRepository{
+		db: db,
+	}
+}
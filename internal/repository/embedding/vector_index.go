@@ -0,0 +1,155 @@
+package embedding
+
+import (
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	IndexMethodHnsw    = "hnsw"
+	IndexMethodIvfflat = "ivfflat"
+)
+
+var IndexMethods = []string{IndexMethodHnsw, IndexMethodIvfflat}
+
+type VectorIndex struct {
+	Name          string
+	Method        string
+	OperatorClass string
+	// Partial indexes only cover the live embeddings.
+	Partial bool
+}
+
+type VectorIndexOptions struct {
+	Method string
+	Metric DistanceMetric
+	// M and EfConstruction shape an HNSW graph, 0 keeps the pgvector defaults
+	// of 16 and 64.
+	M              int
+	EfConstruction int
+	// Lists is the number of IVFFlat partitions, 0 derives it from the row
+	// count the way pgvector recommends.
+	Lists int
+}
+
+// ListVectorIndexes returns the hnsw and ivfflat indexes on embedding_notes.
+func ListVectorIndexes(ctx context.Context, db database.DatabaseQueryer) ([]VectorIndex, error) {
+	rows, err := db.Query(
+		ctx,
+		`
+			SELECT i.relname, am.amname, opc.opcname, ix.indpred IS NOT NULL
+			FROM pg_index ix
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_class t ON t.oid = ix.indrelid
+			JOIN pg_am am ON am.oid = i.relam
+			JOIN pg_opclass opc ON opc.oid = ix.indclass[0]
+			WHERE t.relname = 'embedding_notes'
+				AND am.amname IN ('hnsw', 'ivfflat')
+			ORDER BY i.relname
+		`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]VectorIndex, 0)
+	for rows.Next() {
+		var index VectorIndex
+		err = rows.Scan(&index.Name, &index.Method, &index.OperatorClass, &index.Partial)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, rows.Err()
+}
+
+// ServesMetric tells whether searches by metric can use one of indexes.
+func ServesMetric(indexes []VectorIndex, metric DistanceMetric) bool {
+	return slices.ContainsFunc(indexes, func(index VectorIndex) bool {
+		return index.OperatorClass == metric.OperatorClass()
+	})
+}
+
+func VectorIndexName(method string, metric DistanceMetric) string {
+	return fmt.Sprintf("idx_embedding_notes_embedding_%s_%s", method, metric)
+}
+
+// CreateVectorIndex builds the index concurrently, so writes go on while it
+// builds, and returns its name. It can not run inside a transaction.
+func CreateVectorIndex(ctx context.Context, db database.DatabaseQueryer, options VectorIndexOptions) (string, error) {
+	var parameters []string
+	switch options.Method {
+	case IndexMethodHnsw:
+		if options.M > 0 {
+			parameters = append(parameters, fmt.Sprintf("m = %d", options.M))
+		}
+		if options.EfConstruction > 0 {
+			parameters = append(parameters, fmt.Sprintf("ef_construction = %d", options.EfConstruction))
+		}
+	case IndexMethodIvfflat:
+		lists := options.Lists
+		if lists <= 0 {
+			var count int
+			err := db.QueryRow(ctx, "SELECT COUNT(*) FROM embedding_notes WHERE is_deleted = false").Scan(&count)
+			if err != nil {
+				return "", err
+			}
+			lists = ivfflatLists(count)
+		}
+		parameters = append(parameters, fmt.Sprintf("lists = %d", lists))
+	default:
+		return "", fmt.Errorf("unknown index method %q, expected one of %v", options.Method, IndexMethods)
+	}
+
+	name := VectorIndexName(options.Method, options.Metric)
+	with := ""
+	if len(parameters) > 0 {
+		with = fmt.Sprintf(" WITH (%s)", strings.Join(parameters, ", "))
+	}
+	_, err := db.Exec(
+		ctx,
+		fmt.Sprintf(
+			"CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON embedding_notes USING %s (embedding %s)%s WHERE is_deleted = false",
+			pgx.Identifier{name}.Sanitize(),
+			options.Method,
+			options.Metric.OperatorClass(),
+			with,
+		),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// ivfflatLists is rows / 1000 up to a million rows and sqrt(rows) above.
+func ivfflatLists(rows int) int {
+	if rows > 1_000_000 {
+		return int(math.Sqrt(float64(rows)))
+	}
+
+	return max(1, rows/1000)
+}
+
+func DropVectorIndex(ctx context.Context, db database.DatabaseQueryer, name string) error {
+	indexes, err := ListVectorIndexes(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(indexes, func(index VectorIndex) bool { return index.Name == name }) {
+		return fmt.Errorf("%s is not a vector index of embedding_notes", name)
+	}
+
+	_, err = db.Exec(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", pgx.Identifier{name}.Sanitize()))
+	return err
+}
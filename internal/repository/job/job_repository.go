@@ -0,0 +1,206 @@
+package job
+
+import (
+	jobentity "ai-notetaking-be/internal/entity/job"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IJobRepository interface {
+	UsingTx(ctx context.Context, tx database.DatabaseQueryer) IJobRepository
+	Create(ctx context.Context, jobEntity *jobentity.Job) error
+	Notify(ctx context.Context, channel string, payload string) error
+	ClaimNext(ctx context.Context, queueName string, visibilityTimeout time.Duration, maxAttempts int) (*jobentity.Job, error)
+	Complete(ctx context.Context, id uuid.UUID) error
+	Fail(ctx context.Context, id uuid.UUID, reason string, retryAt *time.Time) error
+	CountByStatus(ctx context.Context, queueName string) (map[string]int, error)
+}
+
+type jobRepository struct {
+	db database.DatabaseQueryer
+}
+
+func (j *jobRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) IJobRepository {
+	return &jobRepository{
+		db: tx,
+	}
+}
+
+func (j *jobRepository) Create(ctx context.Context, jobEntity *jobentity.Job) error {
+	_, err := j.db.Exec(
+		ctx,
+		"INSERT INTO jobs (id, queue_name, payload, status, attempts, available_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		jobEntity.Id,
+		jobEntity.QueueName,
+		jobEntity.Payload,
+		jobEntity.Status,
+		jobEntity.Attempts,
+		jobEntity.AvailableAt,
+		jobEntity.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (j *jobRepository) Notify(ctx context.Context, channel string, payload string) error {
+	_, err := j.db.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClaimNext locks the oldest available job of the queue and marks it as processing.
+// Jobs left in processing longer than visibilityTimeout belong to a crashed worker
+// and are claimed again, unless they already had maxAttempts, then they likely
+// crash the worker themselves and are failed for good. Returns nil when the
+// queue is empty.
+func (j *jobRepository) ClaimNext(ctx context.Context, queueName string, visibilityTimeout time.Duration, maxAttempts int) (*jobentity.Job, error) {
+	now := time.Now()
+	_, err := j.db.Exec(
+		ctx,
+		`
+			UPDATE jobs
+			SET status = $1, last_error = $2, locked_at = NULL
+			WHERE queue_name = $3 AND status = $4 AND locked_at < $5 AND attempts >= $6
+		`,
+		jobentity.StatusFailed,
+		fmt.Sprintf("worker stopped during each of %d attempts", maxAttempts),
+		queueName,
+		jobentity.StatusProcessing,
+		now.Add(-visibilityTimeout),
+		maxAttempts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	row := j.db.QueryRow(
+		ctx,
+		`
+			UPDATE jobs
+			SET status = $1, attempts = attempts + 1, locked_at = $2
+			WHERE id = (
+				SELECT id
+				FROM jobs
+				WHERE queue_name = $3
+					AND (
+						(status = $4 AND available_at <= $2)
+						OR (status = $1 AND locked_at < $5 AND attempts < $6)
+					)
+				ORDER BY available_at
+				FOR UPDATE SKIP LOCKED
+				LIMIT 1
+			)
+			RETURNING id, queue_name, payload, status, attempts, last_error, available_at, locked_at, created_at
+		`,
+		jobentity.StatusProcessing,
+		now,
+		queueName,
+		jobentity.StatusPending,
+		now.Add(-visibilityTimeout),
+		maxAttempts,
+	)
+	var jobEntity jobentity.Job
+	err = row.Scan(
+		&jobEntity.Id,
+		&jobEntity.QueueName,
+		&jobEntity.Payload,
+		&jobEntity.Status,
+		&jobEntity.Attempts,
+		&jobEntity.LastError,
+		&jobEntity.AvailableAt,
+		&jobEntity.LockedAt,
+		&jobEntity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &jobEntity, nil
+}
+
+func (j *jobRepository) Complete(ctx context.Context, id uuid.UUID) error {
+	_, err := j.db.Exec(
+		ctx,
+		"DELETE FROM jobs WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Fail puts the job back to pending until retryAt, or marks it as failed for good
+// when retryAt is nil.
+func (j *jobRepository) Fail(ctx context.Context, id uuid.UUID, reason string, retryAt *time.Time) error {
+	status := jobentity.StatusFailed
+	availableAt := time.Now()
+	if retryAt != nil {
+		status = jobentity.StatusPending
+		availableAt = *retryAt
+	}
+
+	_, err := j.db.Exec(
+		ctx,
+		"UPDATE jobs SET status = $1, last_error = $2, available_at = $3, locked_at = NULL WHERE id = $4",
+		status,
+		reason,
+		availableAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CountByStatus counts the jobs of the queue per status, completed jobs are
+// deleted and never counted.
+func (j *jobRepository) CountByStatus(ctx context.Context, queueName string) (map[string]int, error) {
+	rows, err := j.db.Query(
+		ctx,
+		"SELECT status, COUNT(*) FROM jobs WHERE queue_name = $1 GROUP BY status",
+		queueName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		err = rows.Scan(&status, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+func NewJobRepository(db *pgxpool.Pool) IJobRepository {
+	return &jobRepository{
+		db: db,
+	}
+}
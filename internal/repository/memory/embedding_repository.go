@@ -0,0 +1,234 @@
+package memory
+
+import (
+	embeddingentity "ai-notetaking-be/internal/entity/embedding"
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"ai-notetaking-be/pkg/database"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// similarNoteLimit matches the default limit of the pgvector search.
+const similarNoteLimit = 10
+
+type embeddingRepository struct {
+	store  *Store
+	metric embeddingrepository.DistanceMetric
+}
+
+func (e *embeddingRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) embeddingrepository.IEmbeddingRepository {
+	return e
+}
+
+func (e *embeddingRepository) CreateNoteEmbedding(ctx context.Context, noteEmbedding *embeddingentity.NoteEmbedding) error {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	if _, ok := e.store.notes[noteEmbedding.NoteId]; !ok {
+		return foreignKeyViolation("embedding")
+	}
+	embedding := *noteEmbedding
+	embedding.Embedding = slices.Clone(noteEmbedding.Embedding)
+	e.store.embeddings[embedding.Id] = embedding
+
+	return nil
+}
+
+func (e *embeddingRepository) GetByNoteId(ctx context.Context, noteId uuid.UUID) ([]embeddingentity.NoteEmbedding, error) {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	embeddings := make([]embeddingentity.NoteEmbedding, 0)
+	for _, embedding := range e.store.embeddings {
+		if embedding.IsDeleted || embedding.NoteId != noteId {
+			continue
+		}
+		embedding.Embedding = slices.Clone(embedding.Embedding)
+		embeddings = append(embeddings, embedding)
+	}
+	slices.SortFunc(embeddings, func(a, b embeddingentity.NoteEmbedding) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return embeddings, nil
+}
+
+func (e *embeddingRepository) FindMostSimilarNoteIds(ctx context.Context, embeddingValue []float32) ([]uuid.UUID, error) {
+	notes, err := e.SearchNearest(ctx, embeddingValue, embeddingrepository.SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.NoteId)
+	}
+
+	return ids, nil
+}
+
+// SearchNearest compares embeddingValue to every live embedding with the
+// same distance as the pgvector operator of the metric, so it is always exact
+// and ignores the index settings of options.
+func (e *embeddingRepository) SearchNearest(ctx context.Context, embeddingValue []float32, options embeddingrepository.SearchOptions) ([]embeddingrepository.SimilarNote, error) {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	similar := make([]embeddingrepository.SimilarNote, 0)
+	for _, embedding := range e.store.embeddings {
+		if embedding.IsDeleted || !matches(e.store, embedding, options) {
+			continue
+		}
+		if len(embedding.Embedding) != len(embeddingValue) {
+			return nil, fmt.Errorf("different vector dimensions %d and %d", len(embedding.Embedding), len(embeddingValue))
+		}
+		noteDistance := distance(e.metric, embedding.Embedding, embeddingValue)
+		similar = append(similar, embeddingrepository.SimilarNote{
+			NoteId:     embedding.NoteId,
+			Distance:   noteDistance,
+			Similarity: e.metric.Similarity(noteDistance),
+		})
+	}
+	slices.SortFunc(similar, func(a, b embeddingrepository.SimilarNote) int {
+		return cmp.Compare(a.Distance, b.Distance)
+	})
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = similarNoteLimit
+	}
+
+	return similar[:min(len(similar), limit)], nil
+}
+
+// matches applies the filters of options, the caller holds the lock.
+func matches(store *Store, embedding embeddingentity.NoteEmbedding, options embeddingrepository.SearchOptions) bool {
+	if options.ExcludeNoteId != nil && embedding.NoteId == *options.ExcludeNoteId {
+		return false
+	}
+	if options.Model != "" && embedding.Model != options.Model {
+		return false
+	}
+	if options.NotebookId != nil {
+		notebookId := store.notes[embedding.NoteId].NotebookId
+		return notebookId != nil && *notebookId == *options.NotebookId
+	}
+
+	return true
+}
+
+func distance(metric embeddingrepository.DistanceMetric, a []float32, b []float32) float64 {
+	var dot, normA, normB, l2 float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		l2 += (x - y) * (x - y)
+	}
+
+	switch metric {
+	case embeddingrepository.MetricInnerProduct:
+		return -dot
+	case embeddingrepository.MetricL2:
+		return math.Sqrt(l2)
+	}
+
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// LockNote has nothing to do, the store serializes every write.
+func (e *embeddingRepository) LockNote(ctx context.Context, noteId uuid.UUID) error {
+	return nil
+}
+
+func (e *embeddingRepository) DeleteNoteEmbeddings(ctx context.Context, noteId uuid.UUID, model string, deletedBy string) error {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	e.store.deleteEmbeddings(deletedBy, func(embedding embeddingentity.NoteEmbedding) bool {
+		return embedding.NoteId == noteId && embedding.Model == model
+	})
+
+	return nil
+}
+
+func (e *embeddingRepository) DeleteByNoteId(ctx context.Context, noteId uuid.UUID, deletedBy string) error {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	e.store.deleteEmbeddings(deletedBy, func(embedding embeddingentity.NoteEmbedding) bool {
+		return embedding.NoteId == noteId
+	})
+
+	return nil
+}
+
+func (e *embeddingRepository) DeleteByNotebookId(ctx context.Context, notebookId uuid.UUID, deletedBy string) error {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	e.store.deleteEmbeddings(deletedBy, func(embedding embeddingentity.NoteEmbedding) bool {
+		note := e.store.notes[embedding.NoteId]
+		return note.NotebookId != nil && *note.NotebookId == notebookId
+	})
+
+	return nil
+}
+
+func (e *embeddingRepository) CountStaleNotes(ctx context.Context, model string) (int, error) {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	embeddedAt := make(map[uuid.UUID]time.Time)
+	for _, embedding := range e.store.embeddings {
+		if embedding.IsDeleted || embedding.Model != model {
+			continue
+		}
+		if embedding.CreatedAt.After(embeddedAt[embedding.NoteId]) {
+			embeddedAt[embedding.NoteId] = embedding.CreatedAt
+		}
+	}
+
+	count := 0
+	for _, note := range e.store.notes {
+		if note.IsDeleted {
+			continue
+		}
+		changedAt := note.CreatedAt
+		if note.UpdatedAt != nil {
+			changedAt = *note.UpdatedAt
+		}
+		if embeddedAt[note.Id].Before(changedAt) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *Store) deleteEmbeddings(deletedBy string, match func(embedding embeddingentity.NoteEmbedding) bool) {
+	now := time.Now()
+	for id, embedding := range s.embeddings {
+		if embedding.IsDeleted || !match(embedding) {
+			continue
+		}
+		embedding.IsDeleted = true
+		embedding.DeletedAt = &now
+		embedding.DeletedBy = &deletedBy
+		s.embeddings[id] = embedding
+	}
+}
+
+func NewMemoryEmbeddingRepository(store *Store, metric embeddingrepository.DistanceMetric) embeddingrepository.IEmbeddingRepository {
+	return &embeddingRepository{
+		store:  store,
+		metric: metric,
+	}
+}
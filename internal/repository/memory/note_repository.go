@@ -0,0 +1,199 @@
+package memory
+
+import (
+	noteentity "ai-notetaking-be/internal/entity/note"
+	noterepository "ai-notetaking-be/internal/repository/note"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type noteRepository struct {
+	store *Store
+}
+
+func (n *noteRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) noterepository.INoteRepository {
+	return n
+}
+
+func (n *noteRepository) Create(ctx context.Context, noteEntity *noteentity.Note) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	if _, ok := n.store.notes[noteEntity.Id]; ok {
+		return apperror.Conflict("note already exists")
+	}
+	if !n.store.notebookExists(noteEntity.NotebookId) {
+		return foreignKeyViolation("note")
+	}
+	note := *noteEntity
+	note.Notebook = nil
+	n.store.notes[note.Id] = note
+
+	return nil
+}
+
+func (n *noteRepository) GetById(ctx context.Context, id uuid.UUID) (*noteentity.Note, error) {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	note, ok := n.store.notes[id]
+	if !ok || note.IsDeleted {
+		return nil, apperror.NotFound("note not found")
+	}
+	// Like the LEFT JOIN, deleted notebooks are still attached.
+	if note.NotebookId != nil {
+		if notebook, ok := n.store.notebooks[*note.NotebookId]; ok {
+			note.Notebook = &noteentity.Notebook{
+				Id:   notebook.Id,
+				Name: notebook.Name,
+			}
+		}
+	}
+
+	return &note, nil
+}
+
+func (n *noteRepository) GetByIds(ctx context.Context, ids []uuid.UUID) ([]*noteentity.Note, error) {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	result := make([]*noteentity.Note, 0)
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		note, ok := n.store.notes[id]
+		if !ok || note.IsDeleted || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, &note)
+	}
+
+	return result, nil
+}
+
+func (n *noteRepository) GetAll(ctx context.Context) ([]*noteentity.Note, error) {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	result := make([]*noteentity.Note, 0)
+	for _, note := range n.store.notes {
+		if note.IsDeleted {
+			continue
+		}
+		result = append(result, &note)
+	}
+
+	return result, nil
+}
+
+func (n *noteRepository) GetByNotebookId(ctx context.Context, notebookId uuid.UUID) ([]*noteentity.Note, error) {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	result := make([]*noteentity.Note, 0)
+	for _, note := range n.store.notes {
+		if note.IsDeleted || note.NotebookId == nil || *note.NotebookId != notebookId {
+			continue
+		}
+		result = append(result, &noteentity.Note{Id: note.Id})
+	}
+
+	return result, nil
+}
+
+func (n *noteRepository) Update(ctx context.Context, noteEntity *noteentity.Note) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	note, ok := n.store.notes[noteEntity.Id]
+	if !ok {
+		return nil
+	}
+	if !n.store.notebookExists(noteEntity.NotebookId) {
+		return foreignKeyViolation("note")
+	}
+	note.Title = noteEntity.Title
+	note.Content = noteEntity.Content
+	note.NotebookId = noteEntity.NotebookId
+	note.UpdatedAt = noteEntity.UpdatedAt
+	note.UpdatedBy = noteEntity.UpdatedBy
+	n.store.notes[note.Id] = note
+
+	return nil
+}
+
+func (n *noteRepository) UpdateNoteNotebook(ctx context.Context, noteId uuid.UUID, notebookId *uuid.UUID, updatedBy string) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	note, ok := n.store.notes[noteId]
+	if !ok {
+		return nil
+	}
+	if !n.store.notebookExists(notebookId) {
+		return foreignKeyViolation("note")
+	}
+	now := time.Now()
+	note.NotebookId = notebookId
+	note.UpdatedAt = &now
+	note.UpdatedBy = &updatedBy
+	n.store.notes[noteId] = note
+
+	return nil
+}
+
+func (n *noteRepository) DeleteNote(ctx context.Context, id uuid.UUID, deletedBy string) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	note, ok := n.store.notes[id]
+	if !ok || note.IsDeleted {
+		return apperror.NotFound("note not found")
+	}
+	n.store.notes[id] = deleteNote(note, deletedBy)
+
+	return nil
+}
+
+func (n *noteRepository) DeleteByNotebookId(ctx context.Context, notebookId uuid.UUID, deletedBy string) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	for id, note := range n.store.notes {
+		if note.NotebookId != nil && *note.NotebookId == notebookId {
+			n.store.notes[id] = deleteNote(note, deletedBy)
+		}
+	}
+
+	return nil
+}
+
+func deleteNote(note noteentity.Note, deletedBy string) noteentity.Note {
+	now := time.Now()
+	note.IsDeleted = true
+	note.DeletedAt = &now
+	note.DeletedBy = &deletedBy
+
+	return note
+}
+
+// notebookExists checks the notebook_id and parent_id foreign keys, which like
+// Postgres accept soft deleted notebooks.
+func (s *Store) notebookExists(notebookId *uuid.UUID) bool {
+	if notebookId == nil {
+		return true
+	}
+	_, ok := s.notebooks[*notebookId]
+
+	return ok
+}
+
+func NewMemoryNoteRepository(store *Store) noterepository.INoteRepository {
+	return &noteRepository{
+		store: store,
+	}
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	noteentity "ai-notetaking-be/internal/entity/note"
+	noterepository "ai-notetaking-be/internal/repository/note"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type notebookRepository struct {
+	store *Store
+}
+
+func (n *notebookRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) noterepository.INotebookRepository {
+	return n
+}
+
+func (n *notebookRepository) GetById(ctx context.Context, id uuid.UUID) (*noteentity.Notebook, error) {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	notebook, ok := n.store.notebooks[id]
+	if !ok || notebook.IsDeleted {
+		return nil, apperror.NotFound("notebook not found")
+	}
+
+	return &notebook, nil
+}
+
+func (n *notebookRepository) Create(ctx context.Context, notebookEntity *noteentity.Notebook) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	if _, ok := n.store.notebooks[notebookEntity.Id]; ok {
+		return apperror.Conflict("notebook already exists")
+	}
+	if !n.store.notebookExists(notebookEntity.ParentId) {
+		return foreignKeyViolation("notebook")
+	}
+	n.store.notebooks[notebookEntity.Id] = *notebookEntity
+
+	return nil
+}
+
+func (n *notebookRepository) Update(ctx context.Context, notebookEntity *noteentity.Notebook) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	notebook, ok := n.store.notebooks[notebookEntity.Id]
+	if !ok {
+		return nil
+	}
+	notebook.Name = notebookEntity.Name
+	notebook.UpdatedAt = notebookEntity.UpdatedAt
+	notebook.UpdatedBy = notebookEntity.UpdatedBy
+	n.store.notebooks[notebook.Id] = notebook
+
+	return nil
+}
+
+func (n *notebookRepository) UpdateParent(ctx context.Context, notebookEntity *noteentity.Notebook) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	notebook, ok := n.store.notebooks[notebookEntity.Id]
+	if !ok {
+		return nil
+	}
+	if !n.store.notebookExists(notebookEntity.ParentId) {
+		return foreignKeyViolation("notebook")
+	}
+	notebook.ParentId = notebookEntity.ParentId
+	notebook.UpdatedAt = notebookEntity.UpdatedAt
+	notebook.UpdatedBy = notebookEntity.UpdatedBy
+	n.store.notebooks[notebook.Id] = notebook
+
+	return nil
+}
+
+func (n *notebookRepository) Delete(ctx context.Context, id uuid.UUID, deletedBy string) error {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	notebook, ok := n.store.notebooks[id]
+	if !ok || notebook.IsDeleted {
+		return apperror.NotFound("notebook not found")
+	}
+	now := time.Now()
+	notebook.IsDeleted = true
+	notebook.DeletedAt = &now
+	notebook.DeletedBy = &deletedBy
+	n.store.notebooks[id] = notebook
+
+	return nil
+}
+
+func (n *notebookRepository) GetAll(ctx context.Context) ([]*noteentity.Notebook, error) {
+	n.store.mu.Lock()
+	defer n.store.mu.Unlock()
+
+	var notebooks []*noteentity.Notebook
+	for _, notebook := range n.store.notebooks {
+		if notebook.IsDeleted {
+			continue
+		}
+		notebooks = append(notebooks, &notebook)
+	}
+	slices.SortFunc(notebooks, func(a, b *noteentity.Notebook) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return notebooks, nil
+}
+
+func NewMemoryNotebookRepository(store *Store) noterepository.INotebookRepository {
+	return &notebookRepository{
+		store: store,
+	}
+}
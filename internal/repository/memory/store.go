@@ -0,0 +1,92 @@
+package memory
+
+import (
+	embeddingentity "ai-notetaking-be/internal/entity/embedding"
+	noteentity "ai-notetaking-be/internal/entity/note"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"errors"
+	"maps"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Store holds the notes, notebooks and embeddings of the in-memory
+// repositories, standing in for Postgres in tests and local runs. Rows are
+// kept by value so callers never share state with the store.
+type Store struct {
+	mu         sync.Mutex
+	notes      map[uuid.UUID]noteentity.Note
+	notebooks  map[uuid.UUID]noteentity.Notebook
+	embeddings map[uuid.UUID]embeddingentity.NoteEmbedding
+}
+
+func NewStore() *Store {
+	return &Store{
+		notes:      make(map[uuid.UUID]noteentity.Note),
+		notebooks:  make(map[uuid.UUID]noteentity.Notebook),
+		embeddings: make(map[uuid.UUID]embeddingentity.NoteEmbedding),
+	}
+}
+
+// BeginTx snapshots the store, rolling back restores the snapshot. Writes are
+// visible before commit and transactions are not isolated from each other,
+// which is enough for the single caller of a test.
+func (s *Store) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &tx{
+		store:      s,
+		notes:      maps.Clone(s.notes),
+		notebooks:  maps.Clone(s.notebooks),
+		embeddings: maps.Clone(s.embeddings),
+	}, nil
+}
+
+var errTxClosed = errors.New("memory: transaction is closed")
+
+// tx only implements what the services call, the embedded nil pgx.Tx panics
+// on anything else.
+type tx struct {
+	pgx.Tx
+
+	store      *Store
+	closed     bool
+	notes      map[uuid.UUID]noteentity.Note
+	notebooks  map[uuid.UUID]noteentity.Notebook
+	embeddings map[uuid.UUID]embeddingentity.NoteEmbedding
+}
+
+func (t *tx) Commit(ctx context.Context) error {
+	if t.closed {
+		return errTxClosed
+	}
+	t.closed = true
+
+	return nil
+}
+
+func (t *tx) Rollback(ctx context.Context) error {
+	if t.closed {
+		return errTxClosed
+	}
+	t.closed = true
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+	t.store.notes = t.notes
+	t.store.notebooks = t.notebooks
+	t.store.embeddings = t.embeddings
+
+	return nil
+}
+
+// foreignKeyViolation is what Postgres reports for a write referencing a
+// missing row, translated like the pgx repositories translate it.
+func foreignKeyViolation(entity string) error {
+	return database.TranslateError(&pgconn.PgError{Code: "23503"}, entity)
+}
@@ -0,0 +1,59 @@
+package message
+
+import (
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IProcessedMessageRepository interface {
+	UsingTx(ctx context.Context, tx database.DatabaseQueryer) IProcessedMessageRepository
+	IsProcessed(ctx context.Context, messageId string) (bool, error)
+	MarkProcessed(ctx context.Context, messageId string) error
+}
+
+type processedMessageRepository struct {
+	db database.DatabaseQueryer
+}
+
+func (p *processedMessageRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) IProcessedMessageRepository {
+	return &processedMessageRepository{
+		db: tx,
+	}
+}
+
+func (p *processedMessageRepository) IsProcessed(ctx context.Context, messageId string) (bool, error) {
+	var exists bool
+	err := p.db.QueryRow(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM processed_messages WHERE id = $1)",
+		messageId,
+	).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (p *processedMessageRepository) MarkProcessed(ctx context.Context, messageId string) error {
+	_, err := p.db.Exec(
+		ctx,
+		"INSERT INTO processed_messages (id, processed_at) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING",
+		messageId,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func NewProcessedMessageRepository(db *pgxpool.Pool) IProcessedMessageRepository {
+	return &processedMessageRepository{
+		db: db,
+	}
+}
@@ -2,6 +2,7 @@ package note
 
 import (
 	noteentity "ai-notetaking-be/internal/entity/note"
+	"ai-notetaking-be/pkg/apperror"
 	"ai-notetaking-be/pkg/database"
 	"context"
 	"errors"
@@ -49,7 +50,7 @@ func (n *noteRepository) Create(ctx context.Context, noteEntity *noteentity.Note
 		noteEntity.CreatedBy,
 	)
 	if err != nil {
-		return err
+		return database.TranslateError(err, "note")
 	}
 
 	return nil
@@ -96,7 +97,7 @@ func (n *noteRepository) GetById(ctx context.Context, id uuid.UUID) (*noteentity
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+			return nil, apperror.NotFound("note not found")
 		}
 
 		return nil, err
@@ -126,7 +127,7 @@ func (n *noteRepository) GetByIds(ctx context.Context, ids []uuid.UUID) ([]*note
 	rows, err := n.db.Query(
 		ctx,
 		fmt.Sprintf(
-			"SELECT id, title, content FROM notes WHERE id IN (%s) AND is_deleted = false",
+			"SELECT id, title, content, notebook_id FROM notes WHERE id IN (%s) AND is_deleted = false",
 			whereQuery,
 		),
 	)
@@ -141,6 +142,7 @@ func (n *noteRepository) GetByIds(ctx context.Context, ids []uuid.UUID) ([]*note
 			&noteEntity.Id,
 			&noteEntity.Title,
 			&noteEntity.Content,
+			&noteEntity.NotebookId,
 		)
 		if err != nil {
 			return nil, err
@@ -233,7 +235,7 @@ func (n *noteRepository) Update(ctx context.Context, noteEntity *noteentity.Note
 		noteEntity.Id,
 	)
 	if err != nil {
-		return err
+		return database.TranslateError(err, "note")
 	}
 
 	return nil
@@ -249,16 +251,16 @@ func (n *noteRepository) UpdateNoteNotebook(ctx context.Context, noteId uuid.UUI
 		noteId,
 	)
 	if err != nil {
-		return err
+		return database.TranslateError(err, "note")
 	}
 
 	return nil
 }
 
 func (n *noteRepository) DeleteNote(ctx context.Context, id uuid.UUID, deletedBy string) error {
-	_, err := n.db.Exec(
+	tag, err := n.db.Exec(
 		ctx,
-		"UPDATE notes SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE id = $3",
+		"UPDATE notes SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE id = $3 AND is_deleted = false",
 		time.Now(),
 		deletedBy,
 		id,
@@ -266,6 +268,9 @@ func (n *noteRepository) DeleteNote(ctx context.Context, id uuid.UUID, deletedBy
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("note not found")
+	}
 
 	return nil
 }
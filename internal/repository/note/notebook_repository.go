@@ -2,6 +2,7 @@ package note
 
 import (
 	noteentity "ai-notetaking-be/internal/entity/note"
+	"ai-notetaking-be/pkg/apperror"
 	"ai-notetaking-be/pkg/database"
 	"context"
 	"errors"
@@ -50,7 +51,7 @@ func (n *notebookRepository) GetById(ctx context.Context, id uuid.UUID) (*noteen
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+			return nil, apperror.NotFound("notebook not found")
 		}
 		return nil, err
 	}
@@ -68,7 +69,7 @@ func (n *notebookRepository) Create(ctx context.Context, notebookEntity *noteent
 		notebookEntity.CreatedBy,
 	)
 	if err != nil {
-		return err
+		return database.TranslateError(err, "notebook")
 	}
 
 	return nil
@@ -100,16 +101,16 @@ func (n *notebookRepository) UpdateParent(ctx context.Context, notebookEntity *n
 		notebookEntity.Id,
 	)
 	if err != nil {
-		return err
+		return database.TranslateError(err, "notebook")
 	}
 
 	return nil
 }
 
 func (n *notebookRepository) Delete(ctx context.Context, id uuid.UUID, deletedBy string) error {
-	_, err := n.db.Exec(
+	tag, err := n.db.Exec(
 		ctx,
-		"UPDATE notebook SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE id = $3",
+		"UPDATE notebook SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE id = $3 AND is_deleted = false",
 		time.Now(),
 		deletedBy,
 		id,
@@ -117,6 +118,9 @@ func (n *notebookRepository) Delete(ctx context.Context, id uuid.UUID, deletedBy
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("notebook not found")
+	}
 
 	return nil
 }
@@ -0,0 +1,207 @@
+package webhook
+
+import (
+	webhookentity "ai-notetaking-be/internal/entity/webhook"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IWebhookDeliveryRepository interface {
+	UsingTx(ctx context.Context, tx database.DatabaseQueryer) IWebhookDeliveryRepository
+	Create(ctx context.Context, delivery *webhookentity.Delivery) error
+	GetById(ctx context.Context, id uuid.UUID) (*webhookentity.Delivery, error)
+	GetBySubscriptionId(ctx context.Context, subscriptionId uuid.UUID, limit int) ([]*webhookentity.Delivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*webhookentity.Delivery, error)
+	Requeue(ctx context.Context, id uuid.UUID) (*webhookentity.Delivery, error)
+	UpdateResult(ctx context.Context, delivery *webhookentity.Delivery) error
+}
+
+type webhookDeliveryRepository struct {
+	db database.DatabaseQueryer
+}
+
+const webhookDeliveryColumns = "id, subscription_id, event_id, event_type, payload, status, attempts, response_status, response_body, last_error, next_attempt_at, delivered_at, created_at"
+
+func (w *webhookDeliveryRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) IWebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: tx,
+	}
+}
+
+func (w *webhookDeliveryRepository) Create(ctx context.Context, delivery *webhookentity.Delivery) error {
+	_, err := w.db.Exec(
+		ctx,
+		"INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (subscription_id, event_id) DO NOTHING",
+		delivery.Id,
+		delivery.SubscriptionId,
+		delivery.EventId,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *webhookDeliveryRepository) GetById(ctx context.Context, id uuid.UUID) (*webhookentity.Delivery, error) {
+	rows, err := w.db.Query(
+		ctx,
+		"SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, apperror.NotFound("webhook delivery not found")
+	}
+
+	return deliveries[0], nil
+}
+
+func (w *webhookDeliveryRepository) GetBySubscriptionId(ctx context.Context, subscriptionId uuid.UUID, limit int) ([]*webhookentity.Delivery, error) {
+	rows, err := w.db.Query(
+		ctx,
+		"SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2",
+		subscriptionId,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+// ClaimDue returns pending deliveries whose next attempt is due and pushes their
+// next attempt by lease, so concurrent dispatchers don't send the same delivery.
+func (w *webhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*webhookentity.Delivery, error) {
+	now := time.Now()
+	rows, err := w.db.Query(
+		ctx,
+		`
+			UPDATE webhook_deliveries
+			SET next_attempt_at = $1
+			WHERE id IN (
+				SELECT id
+				FROM webhook_deliveries
+				WHERE status = $2
+					AND next_attempt_at <= $3
+				ORDER BY next_attempt_at
+				FOR UPDATE SKIP LOCKED
+				LIMIT $4
+			)
+			RETURNING `+webhookDeliveryColumns,
+		now.Add(lease),
+		webhookentity.DeliveryStatusPending,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeliveries(rows)
+}
+
+// Requeue makes a delivered or failed delivery pending and due now. A pending
+// one is left alone, it is already scheduled and may be claimed right now.
+func (w *webhookDeliveryRepository) Requeue(ctx context.Context, id uuid.UUID) (*webhookentity.Delivery, error) {
+	rows, err := w.db.Query(
+		ctx,
+		"UPDATE webhook_deliveries SET status = $1, next_attempt_at = $2 WHERE id = $3 AND status <> $1 RETURNING "+webhookDeliveryColumns,
+		webhookentity.DeliveryStatusPending,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return w.GetById(ctx, id)
+	}
+
+	return deliveries[0], nil
+}
+
+func (w *webhookDeliveryRepository) UpdateResult(ctx context.Context, delivery *webhookentity.Delivery) error {
+	_, err := w.db.Exec(
+		ctx,
+		"UPDATE webhook_deliveries SET status = $1, attempts = $2, response_status = $3, response_body = $4, last_error = $5, next_attempt_at = $6, delivered_at = $7 WHERE id = $8",
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.ResponseBody,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanDeliveries(rows pgx.Rows) ([]*webhookentity.Delivery, error) {
+	defer rows.Close()
+
+	result := make([]*webhookentity.Delivery, 0)
+	for rows.Next() {
+		var delivery webhookentity.Delivery
+		err := rows.Scan(
+			&delivery.Id,
+			&delivery.SubscriptionId,
+			&delivery.EventId,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseStatus,
+			&delivery.ResponseBody,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.DeliveredAt,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func NewWebhookDeliveryRepository(db *pgxpool.Pool) IWebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	webhookentity "ai-notetaking-be/internal/entity/webhook"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/database"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IWebhookSubscriptionRepository interface {
+	UsingTx(ctx context.Context, tx database.DatabaseQueryer) IWebhookSubscriptionRepository
+	Create(ctx context.Context, subscription *webhookentity.Subscription) error
+	GetById(ctx context.Context, id uuid.UUID) (*webhookentity.Subscription, error)
+	GetAll(ctx context.Context) ([]*webhookentity.Subscription, error)
+	GetActiveByEventType(ctx context.Context, eventType string) ([]*webhookentity.Subscription, error)
+	Delete(ctx context.Context, id uuid.UUID, deletedBy string) error
+}
+
+type webhookSubscriptionRepository struct {
+	db database.DatabaseQueryer
+}
+
+func (w *webhookSubscriptionRepository) UsingTx(ctx context.Context, tx database.DatabaseQueryer) IWebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{
+		db: tx,
+	}
+}
+
+func (w *webhookSubscriptionRepository) Create(ctx context.Context, subscription *webhookentity.Subscription) error {
+	_, err := w.db.Exec(
+		ctx,
+		"INSERT INTO webhook_subscriptions (id, url, secret, event_types, is_active, created_at, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		subscription.Id,
+		subscription.Url,
+		subscription.Secret,
+		subscription.EventTypes,
+		subscription.IsActive,
+		subscription.CreatedAt,
+		subscription.CreatedBy,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *webhookSubscriptionRepository) GetById(ctx context.Context, id uuid.UUID) (*webhookentity.Subscription, error) {
+	row := w.db.QueryRow(
+		ctx,
+		"SELECT id, url, secret, event_types, is_active, created_at, created_by, updated_at, updated_by FROM webhook_subscriptions WHERE id = $1 AND is_deleted = false",
+		id,
+	)
+	var subscription webhookentity.Subscription
+	err := row.Scan(
+		&subscription.Id,
+		&subscription.Url,
+		&subscription.Secret,
+		&subscription.EventTypes,
+		&subscription.IsActive,
+		&subscription.CreatedAt,
+		&subscription.CreatedBy,
+		&subscription.UpdatedAt,
+		&subscription.UpdatedBy,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.NotFound("webhook subscription not found")
+		}
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (w *webhookSubscriptionRepository) GetAll(ctx context.Context) ([]*webhookentity.Subscription, error) {
+	rows, err := w.db.Query(
+		ctx,
+		"SELECT id, url, secret, event_types, is_active, created_at, created_by, updated_at, updated_by FROM webhook_subscriptions WHERE is_deleted = false ORDER BY created_at DESC",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+// GetActiveByEventType returns the subscriptions that want eventType, an empty
+// event_types filter subscribes to everything.
+func (w *webhookSubscriptionRepository) GetActiveByEventType(ctx context.Context, eventType string) ([]*webhookentity.Subscription, error) {
+	rows, err := w.db.Query(
+		ctx,
+		`
+			SELECT id, url, secret, event_types, is_active, created_at, created_by, updated_at, updated_by
+			FROM webhook_subscriptions
+			WHERE is_deleted = false
+				AND is_active = true
+				AND (cardinality(event_types) = 0 OR $1 = ANY(event_types))
+		`,
+		eventType,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSubscriptions(rows)
+}
+
+func (w *webhookSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID, deletedBy string) error {
+	tag, err := w.db.Exec(
+		ctx,
+		"UPDATE webhook_subscriptions SET is_deleted = true, deleted_at = $1, deleted_by = $2 WHERE id = $3 AND is_deleted = false",
+		time.Now(),
+		deletedBy,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.NotFound("webhook subscription not found")
+	}
+
+	return nil
+}
+
+func scanSubscriptions(rows pgx.Rows) ([]*webhookentity.Subscription, error) {
+	defer rows.Close()
+
+	result := make([]*webhookentity.Subscription, 0)
+	for rows.Next() {
+		var subscription webhookentity.Subscription
+		err := rows.Scan(
+			&subscription.Id,
+			&subscription.Url,
+			&subscription.Secret,
+			&subscription.EventTypes,
+			&subscription.IsActive,
+			&subscription.CreatedAt,
+			&subscription.CreatedBy,
+			&subscription.UpdatedAt,
+			&subscription.UpdatedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func NewWebhookSubscriptionRepository(db *pgxpool.Pool) IWebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,148 @@
+package consumer
+
+import (
+	"ai-notetaking-be/pkg/embedding"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type ConcurrencyOptions struct {
+	MaxConcurrent int
+	// MinConcurrent is the floor adaptive mode never shrinks below.
+	MinConcurrent int
+	Adaptive      bool
+	// LatencyTarget is the provider latency under which adaptive mode considers
+	// the provider healthy enough to grow concurrency again.
+	LatencyTarget time.Duration
+}
+
+// ConcurrencyLimiter bounds how many messages a consumer processes at once. In
+// adaptive mode the bound halves whenever the embedding provider rate limits us
+// and grows by one after a full window of fast successful calls.
+type ConcurrencyLimiter struct {
+	mu       sync.Mutex
+	changed  chan struct{}
+	limit    int
+	inFlight int
+
+	options      ConcurrencyOptions
+	successes    int
+	lastDecrease time.Time
+}
+
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inFlight < l.limit {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (l *ConcurrencyLimiter) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	l.notify()
+}
+
+// Drain waits for in-flight work to finish. Returns how much was still running
+// when ctx expired.
+func (l *ConcurrencyLimiter) Drain(ctx context.Context) int {
+	for {
+		l.mu.Lock()
+		inFlight := l.inFlight
+		changed := l.changed
+		l.mu.Unlock()
+		if inFlight == 0 {
+			return 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return inFlight
+		case <-changed:
+		}
+	}
+}
+
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+// ObserveEmbedding matches embedding.ObserveFunc, wire it with embedding.NewObservedProvider.
+func (l *ConcurrencyLimiter) ObserveEmbedding(err error, latency time.Duration) {
+	if !l.options.Adaptive {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if errors.Is(err, embedding.ErrRateLimited) {
+		l.successes = 0
+		// Calls in flight when the first 429 came back fail too, count them once.
+		if time.Since(l.lastDecrease) < time.Second {
+			return
+		}
+		l.lastDecrease = time.Now()
+		l.limit = max(l.options.MinConcurrent, l.limit/2)
+		slog.Warn("Embedding provider rate limited, concurrency lowered", "limit", l.limit)
+		return
+	}
+	if err != nil || latency > l.options.LatencyTarget {
+		l.successes = 0
+		return
+	}
+
+	l.successes++
+	if l.successes >= l.limit && l.limit < l.options.MaxConcurrent {
+		l.successes = 0
+		l.limit++
+		l.notify()
+	}
+}
+
+func (l *ConcurrencyLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func NewConcurrencyLimiter(options ConcurrencyOptions) *ConcurrencyLimiter {
+	if options.MaxConcurrent < 1 {
+		options.MaxConcurrent = 1
+	}
+	if options.MinConcurrent < 1 || options.MinConcurrent > options.MaxConcurrent {
+		options.MinConcurrent = 1
+	}
+
+	return &ConcurrencyLimiter{
+		changed: make(chan struct{}),
+		limit:   options.MaxConcurrent,
+		options: options,
+	}
+}
@@ -0,0 +1,45 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// consumerGroup runs one consumer per priority lane as a single consumer.
+type consumerGroup struct {
+	consumers []IEmbedNoteConsumerService
+}
+
+func (g *consumerGroup) Consume(ctx context.Context) error {
+	return g.each(func(c IEmbedNoteConsumerService) error {
+		return c.Consume(ctx)
+	})
+}
+
+func (g *consumerGroup) Shutdown(ctx context.Context) error {
+	return g.each(func(c IEmbedNoteConsumerService) error {
+		return c.Shutdown(ctx)
+	})
+}
+
+func (g *consumerGroup) each(fn func(c IEmbedNoteConsumerService) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(g.consumers))
+	for i, c := range g.consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(c)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func NewConsumerGroup(consumers ...IEmbedNoteConsumerService) IEmbedNoteConsumerService {
+	return &consumerGroup{
+		consumers: consumers,
+	}
+}
@@ -1,225 +1,156 @@
 package consumer
 
 import (
-	embeddingentity "ai-notetaking-be/internal/entity/embedding"
-	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
-	noterepository "ai-notetaking-be/internal/repository/note"
-	noteservice "ai-notetaking-be/internal/service/note"
-	"ai-notetaking-be/pkg/gemini"
+	"ai-notetaking-be/pkg/rabbitmq"
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-type EmbeddingModelRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-}
-
-type EmbeddingModelResponse struct {
-	Embedding []float32 `json:"embedding"`
-}
-
 type IEmbedNoteConsumerService interface {
+	// Consume receives messages until ctx is cancelled. Messages already being
+	// processed are not interrupted by ctx, see Shutdown.
 	Consume(ctx context.Context) error
+	// Shutdown waits for in-flight messages until ctx expires, aborts the rest
+	// and releases the consumer's resources.
+	Shutdown(ctx context.Context) error
 }
 
 type embedNoteConsumerService struct {
-	embeddingServerBaseUrl string
-	embeddingModelName     string
+	queueName string
+	conn      *rabbitmq.Connection
 
-	ch *amqp.Channel
-	q  amqp.Queue
+	// workCtx outlives the ctx of Consume so in-flight messages finish, only
+	// Shutdown cancels it.
+	workCtx    context.Context
+	cancelWork context.CancelFunc
 
-	semaphore     chan struct{}
-	maxConcurrent int
+	limiter *ConcurrencyLimiter
 
-	embeddingRepository embeddingrepository.IEmbeddingRepository
-	noterepository      noterepository.INoteRepository
-	db                  *pgxpool.Pool
+	handler MessageHandler
 }
 
+// Consume keeps consuming across reconnects, it only returns once ctx is done.
 func (mq *embedNoteConsumerService) Consume(ctx context.Context) error {
-	msgs, err := mq.ch.ConsumeWithContext(
-		ctx,
-		"",
-		mq.q.Name,
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	for msg := range msgs {
-		mq.semaphore <- struct{}{}
-
-		go mq.processMessage(ctx, msg)
-	}
-
-	return nil
-}
-
-func (mq *embedNoteConsumerService) processMessage(ctx context.Context, msg amqp.Delivery) error {
-	defer func() {
-		<-mq.semaphore
-	}()
-	var err error
-	defer func() {
+	for {
+		ch, err := mq.conn.WaitReady(ctx)
 		if err != nil {
-			nackErr := msg.Nack(false, false)
-			if nackErr != nil {
-				panic(nackErr)
+			if ctx.Err() != nil {
+				return nil
 			}
+			return err
 		}
-	}()
-	var dest noteservice.EmbedCreatedNoteMessage
-	err = json.Unmarshal(msg.Body, &dest)
-	if err != nil {
-		return err
-	}
 
-	tx, err := mq.db.Begin(ctx)
-	if err != nil {
-		log.Println(err)
-		return err
-	}
-	defer func() {
+		msgs, err := ch.ConsumeWithContext(
+			ctx,
+			"",
+			mq.queueName,
+			false,
+			false,
+			false,
+			false,
+			nil,
+		)
 		if err != nil {
-			log.Println(err)
-			rollbackErr := tx.Rollback(ctx)
-			if rollbackErr != nil {
-				panic(rollbackErr)
+			// The channel is most likely being replaced, give the supervisor a moment.
+			slog.Warn("Consuming RabbitMQ queue failed", "queue", mq.queueName, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
 			}
+			continue
 		}
-	}()
-	embedRepo := mq.embeddingRepository.UsingTx(ctx, tx)
-	noteRepo := mq.noterepository.UsingTx(ctx, tx)
 
-	note, err := noteRepo.GetById(ctx, dest.NoteId)
-	if err != nil {
-		return err
-	}
-	if dest.DeleteOldEmbedding {
-		err = embedRepo.DeleteNoteEmbeddings(ctx, dest.NoteId, "System")
-		if err != nil {
-			return err
+		for msg := range msgs {
+			err = mq.limiter.Acquire(ctx)
+			if err != nil {
+				break
+			}
+
+			go mq.processMessage(mq.workCtx, msg)
 		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		slog.Warn("RabbitMQ delivery channel closed, resuming once reconnected", "queue", mq.queueName)
 	}
+}
 
-	notebookName := "-"
-	if note.Notebook != nil {
-		notebookName = note.Notebook.Name
+func (mq *embedNoteConsumerService) Shutdown(ctx context.Context) error {
+	var err error
+	abandoned := mq.limiter.Drain(ctx)
+	if abandoned > 0 {
+		err = fmt.Errorf("abandoned %d in-flight messages, they will be redelivered", abandoned)
 	}
-	document := fmt.Sprintf(
-		`Notebook: %s\nTitle: %s\nContent: %s\nCreated at: %s`,
-		notebookName,
-		note.Title,
-		note.Content,
-		note.CreatedAt.Format(time.RFC3339),
-	)
-	// req := EmbeddingModelRequest{
-	// 	Model:  mq.embeddingModelName,
-	// 	Prompt: document,
-	// }
-	// reqJson, _ := json.Marshal(req)
-	// res, err := http.Post(fmt.Sprintf("%s/api/embeddings", mq.embeddingServerBaseUrl), "application/json", bytes.NewBuffer(reqJson))
-	// if err != nil {
-	// 	log.Println(err)
-	// 	return err
-	// }
-
-	// var embeddingResponse EmbeddingModelResponse
-	// err = json.NewDecoder(res.Body).Decode(&embeddingResponse)
-	// if err != nil {
-	// 	log.Println(err)
-	// 	return err
-	// }
-
-	embeddingValue, err := gemini.GetEmbedding(os.Getenv("GEMINI_API_KEY"), document, "RETRIEVAL_DOCUMENT")
-	if err != nil {
-		log.Println(err)
-		return err
+	mq.cancelWork()
+
+	closeErr := mq.conn.Close()
+	if closeErr != nil && err == nil {
+		err = closeErr
 	}
 
-	embeddingText := embeddingentity.NoteEmbedding{
-		Id:           uuid.New(),
-		NoteId:       dest.NoteId,
-		OriginalText: document,
-		Embedding:    embeddingValue.Embedding.Values,
-		CreatedAt:    time.Now(),
-		CreatedBy:    "System",
+	return err
+}
+
+func (mq *embedNoteConsumerService) processMessage(ctx context.Context, delivery amqp.Delivery) {
+	defer mq.limiter.Release()
+
+	metadata := make(map[string]string)
+	for key, value := range delivery.Headers {
+		metadata[key] = fmt.Sprint(value)
 	}
-	err = embedRepo.CreateNoteEmbedding(ctx, &embeddingText)
-	if err != nil {
-		log.Println(err)
-		return err
+	msg := Message{
+		Id:       delivery.MessageId,
+		Payload:  delivery.Body,
+		Metadata: metadata,
 	}
 
-	err = tx.Commit(ctx)
+	err := mq.handler(ctx, &msg)
 	if err != nil {
-		log.Println(err)
-		return err
+		err = delivery.Nack(false, false)
+		if err != nil {
+			slog.ErrorContext(ctx, "Nack failed", "message_id", msg.Id, "error", err)
+		}
+		return
 	}
-	err = msg.Ack(false)
+
+	err = delivery.Ack(false)
 	if err != nil {
-		log.Println(err)
-		return err
+		slog.ErrorContext(ctx, "Ack failed", "message_id", msg.Id, "error", err)
 	}
-
-	return nil
 }
 
 func NewEmbedNoteConsumerService(
 	connectionString string,
 	queueName string,
-	db *pgxpool.Pool,
-	embeddingServerBaseUrl string,
-	embeddingModelName string,
-	embeddingRepository embeddingrepository.IEmbeddingRepository,
-	noteRepository noterepository.INoteRepository,
+	handler MessageHandler,
+	limiter *ConcurrencyLimiter,
+	prefetch int,
 ) IEmbedNoteConsumerService {
-	conn, err := amqp.Dial(connectionString)
-	if err != nil {
-		panic(fmt.Sprintf("RabbitMQ connection error, %s", err))
-	}
+	declareQueue := rabbitmq.DeclareQueue(queueName)
+	conn := rabbitmq.NewConnection(connectionString, func(ch *amqp.Channel) error {
+		err := declareQueue(ch)
+		if err != nil {
+			return err
+		}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		panic(fmt.Sprintf("RabbitMQ creating channel error, %s", err))
-	}
+		// Without a prefetch the broker pushes the whole queue to this consumer.
+		return ch.Qos(prefetch, 0, false)
+	})
 
-	q, err := ch.QueueDeclare(
-		queueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		panic(fmt.Sprintf("RabbitMQ declaring queue error, %s", err))
-	}
+	workCtx, cancelWork := context.WithCancel(context.Background())
 
 	return &embedNoteConsumerService{
-		ch:                     ch,
-		q:                      q,
-		embeddingServerBaseUrl: embeddingServerBaseUrl,
-		embeddingModelName:     embeddingModelName,
-		db:                     db,
-		maxConcurrent:          100,
-		semaphore:              make(chan struct{}, 100),
-		embeddingRepository:    embeddingRepository,
-		noterepository:         noteRepository,
+		queueName:  queueName,
+		conn:       conn,
+		limiter:    limiter,
+		handler:    handler,
+		workCtx:    workCtx,
+		cancelWork: cancelWork,
 	}
 }
@@ -1,37 +1,25 @@
 package consumer
 
 import (
-	embeddingentity "ai-notetaking-be/internal/entity/embedding"
-	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
-	noterepository "ai-notetaking-be/internal/repository/note"
-	noteservice "ai-notetaking-be/internal/service/note"
-	"ai-notetaking-be/pkg/gemini"
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"os"
-	"time"
+	"log/slog"
 
 	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type embedNoteInMemoryConsumerService struct {
-	queueName              string
-	embeddingServerBaseUrl string
-	embeddingModelName     string
+	queueName string
 
 	pubSub *gochannel.GoChannel
 
-	semaphore     chan struct{}
-	maxConcurrent int
+	workCtx    context.Context
+	cancelWork context.CancelFunc
 
-	embeddingRepository embeddingrepository.IEmbeddingRepository
-	noterepository      noterepository.INoteRepository
-	db                  *pgxpool.Pool
+	limiter *ConcurrencyLimiter
+
+	handler MessageHandler
 }
 
 func (mq *embedNoteInMemoryConsumerService) Consume(ctx context.Context) error {
@@ -40,139 +28,69 @@ func (mq *embedNoteInMemoryConsumerService) Consume(ctx context.Context) error {
 		return err
 	}
 
-	// go func() {
-	// 	for msg := range messages {
-	// 		log.Print("Consuming...")
-	// 		mq.processMessage(ctx, msg)
-	// 		log.Print("Finished consuming.")
-	// 	}
-	// }()
-
-	// Concurrent
 	go func() {
 		for msg := range messages {
-			log.Print("Consuming...")
-			mq.semaphore <- struct{}{}
+			err := mq.limiter.Acquire(ctx)
+			if err != nil {
+				// Shutting down, gochannel has nowhere to keep the message.
+				return
+			}
 
-			go mq.processMessage(ctx, msg)
-			log.Print("Finished consuming.")
+			go mq.processMessage(mq.workCtx, msg)
 		}
 	}()
 
 	return nil
 }
 
-func (mq *embedNoteInMemoryConsumerService) processMessage(ctx context.Context, msg *message.Message) error {
-	// Activate if concurrent
-	defer func() {
-		<-mq.semaphore
-	}()
-	defer msg.Nack()
-
-	var dest noteservice.EmbedCreatedNoteMessage
-	err := json.Unmarshal(msg.Payload, &dest)
-	if err != nil {
-		return err
+func (mq *embedNoteInMemoryConsumerService) Shutdown(ctx context.Context) error {
+	var err error
+	abandoned := mq.limiter.Drain(ctx)
+	if abandoned > 0 {
+		err = fmt.Errorf("abandoned %d in-flight messages, they are lost", abandoned)
 	}
+	mq.cancelWork()
 
-	tx, err := mq.db.Begin(ctx)
-	if err != nil {
-		log.Println(err)
-		return err
+	closeErr := mq.pubSub.Close()
+	if closeErr != nil && err == nil {
+		err = closeErr
 	}
-	defer tx.Rollback(ctx)
-
-	embedRepo := mq.embeddingRepository.UsingTx(ctx, tx)
-	noteRepo := mq.noterepository.UsingTx(ctx, tx)
 
-	note, err := noteRepo.GetById(ctx, dest.NoteId)
-	if err != nil {
-		return err
-	}
-	if dest.DeleteOldEmbedding {
-		err = embedRepo.DeleteNoteEmbeddings(ctx, dest.NoteId, "System")
-		if err != nil {
-			return err
-		}
-	}
+	return err
+}
 
-	notebookName := "-"
-	if note.Notebook != nil {
-		notebookName = note.Notebook.Name
-	}
-	document := fmt.Sprintf(
-		`Notebook: %s\nTitle: %s\nContent: %s\nCreated at: %s`,
-		notebookName,
-		note.Title,
-		note.Content,
-		note.CreatedAt.Format(time.RFC3339),
-	)
-	// req := EmbeddingModelRequest{
-	// 	Model:  mq.embeddingModelName,
-	// 	Prompt: document,
-	// }
-	// reqJson, _ := json.Marshal(req)
-	// res, err := http.Post(fmt.Sprintf("%s/api/embeddings", mq.embeddingServerBaseUrl), "application/json", bytes.NewBuffer(reqJson))
-	// if err != nil {
-	// 	log.Println(err)
-	// 	return err
-	// }
-
-	// var embeddingResponse EmbeddingModelResponse
-	// err = json.NewDecoder(res.Body).Decode(&embeddingResponse)
-	// if err != nil {
-	// 	log.Println(err)
-	// 	return err
-	// }
-
-	embeddingValue, err := gemini.GetEmbedding(os.Getenv("GEMINI_API_KEY"), document, "RETRIEVAL_DOCUMENT")
-	if err != nil {
-		log.Println(err)
-		return err
-	}
+func (mq *embedNoteInMemoryConsumerService) processMessage(ctx context.Context, wmMsg *message.Message) {
+	defer mq.limiter.Release()
 
-	embeddingText := embeddingentity.NoteEmbedding{
-		Id:           uuid.New(),
-		NoteId:       dest.NoteId,
-		OriginalText: document,
-		Embedding:    embeddingValue.Embedding.Values,
-		CreatedAt:    time.Now(),
-		CreatedBy:    "System",
-	}
-	err = embedRepo.CreateNoteEmbedding(ctx, &embeddingText)
-	if err != nil {
-		log.Println(err)
-		return err
+	msg := Message{
+		Id:       wmMsg.UUID,
+		Payload:  wmMsg.Payload,
+		Metadata: wmMsg.Metadata,
 	}
 
-	err = tx.Commit(ctx)
+	err := mq.handler(ctx, &msg)
 	if err != nil {
-		log.Println(err)
-		return err
+		// gochannel redelivers nacked messages forever and has no dead letter queue,
+		// retries already happened in the handler chain so the message is dropped.
+		slog.ErrorContext(ctx, "Dropping message", "message_id", msg.Id, "error", err)
 	}
-	msg.Ack()
-
-	return nil
+	wmMsg.Ack()
 }
 
 func NewInMemoryConsumer(
 	pubSub *gochannel.GoChannel,
 	queueName string,
-	db *pgxpool.Pool,
-	embeddingServerBaseUrl string,
-	embeddingModelName string,
-	embeddingRepository embeddingrepository.IEmbeddingRepository,
-	noteRepository noterepository.INoteRepository,
+	handler MessageHandler,
+	limiter *ConcurrencyLimiter,
 ) IEmbedNoteConsumerService {
+	workCtx, cancelWork := context.WithCancel(context.Background())
+
 	return &embedNoteInMemoryConsumerService{
-		queueName:              queueName,
-		embeddingServerBaseUrl: embeddingServerBaseUrl,
-		embeddingModelName:     embeddingModelName,
-		db:                     db,
-		maxConcurrent:          100,
-		semaphore:              make(chan struct{}, 100),
-		embeddingRepository:    embeddingRepository,
-		noterepository:         noteRepository,
-		pubSub:                 pubSub,
+		queueName:  queueName,
+		limiter:    limiter,
+		handler:    handler,
+		pubSub:     pubSub,
+		workCtx:    workCtx,
+		cancelWork: cancelWork,
 	}
 }
@@ -0,0 +1,345 @@
+package consumer
+
+import (
+	embeddingentity "ai-notetaking-be/internal/entity/embedding"
+	evententity "ai-notetaking-be/internal/entity/event"
+	noteentity "ai-notetaking-be/internal/entity/note"
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	noterepository "ai-notetaking-be/internal/repository/note"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/embedding"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EmbedNoteEventTypes are the events after which a note's document changes and
+// has to be embedded again.
+var EmbedNoteEventTypes = []string{
+	evententity.TypeNoteCreated,
+	evententity.TypeNoteUpdated,
+	evententity.TypeNoteMoved,
+	evententity.TypeNotebookRenamed,
+}
+
+// IEmbedNotePipeline turns note events into stored embeddings:
+// decode → load note → build document → embed → persist.
+type IEmbedNotePipeline interface {
+	HandleEvent(ctx context.Context, envelope *evententity.Envelope) error
+	// Shutdown embeds the notes still held back by debouncing, call it after
+	// the consumers stopped and before the database closes.
+	Shutdown(ctx context.Context) error
+}
+
+type embedNotePipeline struct {
+	embeddingRepository embeddingrepository.IEmbeddingRepository
+	noteRepository      noterepository.INoteRepository
+	embeddingProvider   embedding.IEmbeddingProvider
+	db                  *pgxpool.Pool
+
+	options   EmbedNotePipelineOptions
+	debouncer *noteDebouncer
+	coalescer *noteCoalescer
+	// Lanes batch separately so interactive notes never wait for a bulk batch to fill.
+	interactiveBatcher *embeddingBatcher
+	bulkBatcher        *embeddingBatcher
+}
+
+type EmbedNotePipelineOptions struct {
+	// BatchSize is the most documents embedded in one provider call, capped by
+	// the provider's own limit. 1 disables batching.
+	BatchSize int
+	// BatchWait is how long a document waits for others to fill its batch.
+	BatchWait time.Duration
+	// DebounceWindow is how long a note has to go without updates before it is
+	// embedded again. 0 disables debouncing.
+	DebounceWindow time.Duration
+	// DebounceMaxDelay bounds the wait of a note that keeps being updated.
+	DebounceMaxDelay time.Duration
+}
+
+func (p *embedNotePipeline) HandleEvent(ctx context.Context, envelope *evententity.Envelope) error {
+	noteIds, err := p.decode(ctx, envelope)
+	if err != nil {
+		return err
+	}
+
+	// Autosaving clients update a note every few seconds, embed once edits
+	// settle. The message is done right away so waiting does not hold a
+	// consumer slot.
+	if envelope.Type == evententity.TypeNoteUpdated && p.options.DebounceWindow > 0 {
+		noteId := noteIds[0]
+		p.debouncer.Schedule(ctx, noteId, func(ctx context.Context) {
+			p.embedSettledNote(ctx, noteId)
+		})
+		return nil
+	}
+
+	batcher := p.interactiveBatcher
+	if envelope.IsBulk() {
+		batcher = p.bulkBatcher
+	}
+
+	if len(noteIds) == 1 {
+		return p.embedNoteOnce(ctx, noteIds[0], batcher)
+	}
+
+	// Bulk events embed their notes concurrently so they end up in shared batches.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	slots := make(chan struct{}, p.options.BatchSize)
+	for _, noteId := range noteIds {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := p.embedNoteOnce(ctx, noteId, batcher)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// embedSettledNote runs outside of any message, so it retries by itself.
+// A note that still fails stays stale until its next update.
+func (p *embedNotePipeline) embedSettledNote(ctx context.Context, noteId uuid.UUID) {
+	wait := time.Second
+	for attempt := 1; ; attempt++ {
+		err := p.embedNoteOnce(ctx, noteId, p.interactiveBatcher)
+		if err == nil {
+			return
+		}
+		if IsPermanent(err) || attempt == 3 {
+			slog.ErrorContext(ctx, "Embedding updated note failed", "note_id", noteId, "attempts", attempt, "error", err)
+			return
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+func (p *embedNotePipeline) Shutdown(ctx context.Context) error {
+	return p.debouncer.Flush(ctx)
+}
+
+func (p *embedNotePipeline) embedNoteOnce(ctx context.Context, noteId uuid.UUID, batcher *embeddingBatcher) error {
+	ran, err := p.coalescer.Do(noteId, func() error {
+		return p.embedNote(ctx, noteId, batcher)
+	})
+	if err != nil {
+		return err
+	}
+	if !ran {
+		slog.DebugContext(ctx, "Embedding superseded by a newer job", "note_id", noteId)
+	}
+
+	return nil
+}
+
+// decode resolves the notes whose embedding is affected by the event.
+func (p *embedNotePipeline) decode(ctx context.Context, envelope *evententity.Envelope) ([]uuid.UUID, error) {
+	switch envelope.Type {
+	case evententity.TypeNoteCreated:
+		var data evententity.NoteCreated
+		err := envelope.DecodeData(&data)
+		if err != nil {
+			return nil, Permanent(err)
+		}
+		return []uuid.UUID{data.NoteId}, nil
+	case evententity.TypeNoteUpdated:
+		var data evententity.NoteUpdated
+		err := envelope.DecodeData(&data)
+		if err != nil {
+			return nil, Permanent(err)
+		}
+		return []uuid.UUID{data.NoteId}, nil
+	case evententity.TypeNoteMoved:
+		var data evententity.NoteMoved
+		err := envelope.DecodeData(&data)
+		if err != nil {
+			return nil, Permanent(err)
+		}
+		return []uuid.UUID{data.NoteId}, nil
+	case evententity.TypeNotebookRenamed:
+		var data evententity.NotebookRenamed
+		err := envelope.DecodeData(&data)
+		if err != nil {
+			return nil, Permanent(err)
+		}
+		notes, err := p.noteRepository.GetByNotebookId(ctx, data.NotebookId)
+		if err != nil {
+			return nil, err
+		}
+		noteIds := make([]uuid.UUID, 0, len(notes))
+		for _, note := range notes {
+			noteIds = append(noteIds, note.Id)
+		}
+		return noteIds, nil
+	}
+
+	return nil, Permanent(fmt.Errorf("event %s is not handled by the embedding pipeline", envelope.Type))
+}
+
+func (p *embedNotePipeline) embedNote(ctx context.Context, noteId uuid.UUID, batcher *embeddingBatcher) error {
+	note, err := p.loadNote(ctx, noteId)
+	if apperror.Is(err, apperror.KindNotFound) {
+		slog.InfoContext(ctx, "Note no longer exists, skipping embedding", "note_id", noteId)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	document := p.buildDocument(note)
+
+	return batcher.Submit(ctx, noteId, document)
+}
+
+func (p *embedNotePipeline) loadNote(ctx context.Context, noteId uuid.UUID) (*noteentity.Note, error) {
+	return p.noteRepository.GetById(ctx, noteId)
+}
+
+func (p *embedNotePipeline) buildDocument(note *noteentity.Note) string {
+	notebookName := "-"
+	if note.Notebook != nil {
+		notebookName = note.Notebook.Name
+	}
+
+	return fmt.Sprintf(
+		`Notebook: %s\nTitle: %s\nContent: %s\nCreated at: %s`,
+		notebookName,
+		note.Title,
+		note.Content,
+		note.CreatedAt.Format(time.RFC3339),
+	)
+}
+
+// embedAndPersist is the batcher's flush: one provider call for the whole batch.
+func (p *embedNotePipeline) embedAndPersist(ctx context.Context, items []*batchItem) error {
+	documents := make([]string, 0, len(items))
+	for _, item := range items {
+		documents = append(documents, item.document)
+	}
+
+	var embeddingValues [][]float32
+	if len(documents) == 1 {
+		embeddingValue, err := p.embeddingProvider.Embed(ctx, documents[0], embedding.TaskTypeRetrievalDocument)
+		if err != nil {
+			return err
+		}
+		embeddingValues = [][]float32{embeddingValue}
+	} else {
+		var err error
+		embeddingValues, err = p.embeddingProvider.EmbedBatch(ctx, documents, embedding.TaskTypeRetrievalDocument)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.persist(ctx, items, embeddingValues)
+}
+
+// persist replaces the stored embeddings of a batch in a single transaction so a
+// failure never leaves a note without its previous embedding. The note locks
+// together with the unique index keep at most one live embedding per note and model.
+func (p *embedNotePipeline) persist(ctx context.Context, items []*batchItem, embeddingValues [][]float32) error {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	embedRepo := p.embeddingRepository.UsingTx(ctx, tx)
+
+	// Lock in a stable order so two batches sharing notes can't deadlock.
+	noteIds := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		noteIds = append(noteIds, item.noteId)
+	}
+	slices.SortFunc(noteIds, func(a, b uuid.UUID) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	for _, noteId := range noteIds {
+		err = embedRepo.LockNote(ctx, noteId)
+		if err != nil {
+			return err
+		}
+	}
+
+	model := p.embeddingProvider.Model()
+	now := time.Now()
+	for i, item := range items {
+		err = embedRepo.DeleteNoteEmbeddings(ctx, item.noteId, model, "System")
+		if err != nil {
+			return err
+		}
+
+		embeddingText := embeddingentity.NoteEmbedding{
+			Id:           uuid.New(),
+			NoteId:       item.noteId,
+			Model:        model,
+			OriginalText: item.document,
+			Embedding:    embeddingValues[i],
+			CreatedAt:    now,
+			CreatedBy:    "System",
+		}
+		err = embedRepo.CreateNoteEmbedding(ctx, &embeddingText)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		slog.InfoContext(item.ctx, "Stored note embedding", "note_id", item.noteId, "model", model, "batch_size", len(items))
+	}
+
+	return nil
+}
+
+func NewEmbedNotePipeline(
+	db *pgxpool.Pool,
+	embeddingRepository embeddingrepository.IEmbeddingRepository,
+	noteRepository noterepository.INoteRepository,
+	embeddingProvider embedding.IEmbeddingProvider,
+	options EmbedNotePipelineOptions,
+) IEmbedNotePipeline {
+	options.BatchSize = max(1, min(options.BatchSize, embeddingProvider.MaxBatchSize()))
+
+	p := &embedNotePipeline{
+		embeddingRepository: embeddingRepository,
+		noteRepository:      noteRepository,
+		embeddingProvider:   embeddingProvider,
+		db:                  db,
+		options:             options,
+		debouncer:           newNoteDebouncer(options.DebounceWindow, options.DebounceMaxDelay),
+		coalescer:           newNoteCoalescer(),
+	}
+	p.interactiveBatcher = newEmbeddingBatcher(options.BatchSize, options.BatchWait, p.embedAndPersist)
+	p.bulkBatcher = newEmbeddingBatcher(options.BatchSize, options.BatchWait, p.embedAndPersist)
+
+	return p
+}
@@ -0,0 +1,199 @@
+package consumer
+
+import (
+	jobentity "ai-notetaking-be/internal/entity/job"
+	jobrepository "ai-notetaking-be/internal/repository/job"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type embedNotePostgresConsumerService struct {
+	queueName string
+
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	maxAttempts       int
+
+	limiter *ConcurrencyLimiter
+
+	handler MessageHandler
+
+	workCtx    context.Context
+	cancelWork context.CancelFunc
+
+	jobRepository jobrepository.IJobRepository
+	db            *pgxpool.Pool
+}
+
+func (mq *embedNotePostgresConsumerService) Consume(ctx context.Context) error {
+	conn, err := mq.listen(ctx)
+	if err != nil {
+		return err
+	}
+
+	wakeUp := make(chan struct{}, 1)
+	go mq.waitForNotifications(ctx, conn, wakeUp)
+	go mq.dispatch(ctx, wakeUp)
+
+	return nil
+}
+
+func (mq *embedNotePostgresConsumerService) listen(ctx context.Context) (*pgxpool.Conn, error) {
+	conn, err := mq.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, fmt.Sprintf("LISTEN %s", pgx.Identifier{mq.queueName}.Sanitize()))
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (mq *embedNotePostgresConsumerService) waitForNotifications(ctx context.Context, conn *pgxpool.Conn, wakeUp chan<- struct{}) {
+	defer func() {
+		if conn != nil {
+			conn.Release()
+		}
+	}()
+
+	for {
+		if conn == nil {
+			var err error
+			conn, err = mq.listen(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Warn("Listening for jobs failed", "queue", mq.queueName, "error", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(mq.pollInterval):
+				}
+				continue
+			}
+		}
+
+		_, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// The connection is unusable after a failed wait, drop it and listen again.
+			slog.Warn("Waiting for job notifications failed", "queue", mq.queueName, "error", err)
+			conn.Hijack().Close(context.Background())
+			conn = nil
+			continue
+		}
+
+		select {
+		case wakeUp <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (mq *embedNotePostgresConsumerService) dispatch(ctx context.Context, wakeUp <-chan struct{}) {
+	for {
+		err := mq.limiter.Acquire(ctx)
+		if err != nil {
+			return
+		}
+
+		job, err := mq.jobRepository.ClaimNext(ctx, mq.queueName, mq.visibilityTimeout, mq.maxAttempts)
+		if err != nil || job == nil {
+			mq.limiter.Release()
+			if err != nil {
+				slog.Error("Claiming job failed", "queue", mq.queueName, "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-wakeUp:
+			case <-time.After(mq.pollInterval):
+			}
+			continue
+		}
+
+		go mq.processJob(mq.workCtx, job)
+	}
+}
+
+// Shutdown leaves abandoned jobs in processing, they are claimed again once the
+// visibility timeout passes.
+func (mq *embedNotePostgresConsumerService) Shutdown(ctx context.Context) error {
+	var err error
+	abandoned := mq.limiter.Drain(ctx)
+	if abandoned > 0 {
+		err = fmt.Errorf("abandoned %d in-flight jobs, they are retried after %s", abandoned, mq.visibilityTimeout)
+	}
+	mq.cancelWork()
+
+	return err
+}
+
+func (mq *embedNotePostgresConsumerService) processJob(ctx context.Context, job *jobentity.Job) {
+	defer mq.limiter.Release()
+
+	msg := Message{
+		Id:       job.Id.String(),
+		Payload:  job.Payload,
+		Metadata: make(map[string]string),
+		Attempt:  job.Attempts,
+	}
+	err := mq.handler(ctx, &msg)
+	// Shutdown cancels ctx, the outcome of a finished handler is still recorded
+	// so the job is not claimed again.
+	recordCtx := context.WithoutCancel(ctx)
+	if err != nil {
+		var retryAt *time.Time
+		if job.Attempts < mq.maxAttempts && !IsPermanent(err) {
+			at := time.Now().Add(time.Duration(job.Attempts*job.Attempts) * time.Second)
+			retryAt = &at
+		}
+		slog.WarnContext(ctx, "Job failed", "job_id", job.Id, "attempt", job.Attempts, "retry_at", retryAt, "error", err)
+		err = mq.jobRepository.Fail(recordCtx, job.Id, err.Error(), retryAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Recording job failure failed", "job_id", job.Id, "error", err)
+		}
+		return
+	}
+
+	err = mq.jobRepository.Complete(recordCtx, job.Id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Completing job failed", "job_id", job.Id, "error", err)
+	}
+}
+
+func NewPostgresConsumer(
+	queueName string,
+	db *pgxpool.Pool,
+	jobRepository jobrepository.IJobRepository,
+	handler MessageHandler,
+	limiter *ConcurrencyLimiter,
+) IEmbedNoteConsumerService {
+	workCtx, cancelWork := context.WithCancel(context.Background())
+
+	return &embedNotePostgresConsumerService{
+		queueName:         queueName,
+		pollInterval:      5 * time.Second,
+		visibilityTimeout: 5 * time.Minute,
+		maxAttempts:       5,
+		db:                db,
+		limiter:           limiter,
+		jobRepository:     jobRepository,
+		handler:           handler,
+		workCtx:           workCtx,
+		cancelWork:        cancelWork,
+	}
+}
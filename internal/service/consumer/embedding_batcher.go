@@ -0,0 +1,110 @@
+package consumer
+
+import (
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type batchItem struct {
+	ctx      context.Context
+	noteId   uuid.UUID
+	document string
+	done     chan error
+}
+
+type batchFlushFunc func(ctx context.Context, items []*batchItem) error
+
+// embeddingBatcher gathers documents submitted by concurrent workers and flushes
+// them together once maxSize documents are waiting or maxWait has passed since
+// the first one arrived. Submit blocks until the batch holding the document is
+// stored, so callers still ack only after their note is persisted.
+type embeddingBatcher struct {
+	items   chan *batchItem
+	maxSize int
+	maxWait time.Duration
+	flush   batchFlushFunc
+}
+
+func (b *embeddingBatcher) Submit(ctx context.Context, noteId uuid.UUID, document string) error {
+	item := &batchItem{
+		ctx:      ctx,
+		noteId:   noteId,
+		document: document,
+		done:     make(chan error, 1),
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case b.items <- item:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-item.done:
+		return err
+	}
+}
+
+func (b *embeddingBatcher) run() {
+	for first := range b.items {
+		batch := []*batchItem{first}
+		timer := time.NewTimer(b.maxWait)
+
+	collect:
+		for len(batch) < b.maxSize {
+			select {
+			case item := <-b.items:
+				batch = append(batch, item)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		go b.flushBatch(batch)
+	}
+}
+
+func (b *embeddingBatcher) flushBatch(batch []*batchItem) {
+	// One waiter giving up must not fail the documents of everyone else.
+	ctx := context.WithoutCancel(batch[0].ctx)
+	// The batch runs in the trace of its first document and links the others.
+	links := make([]trace.Link, 0, len(batch)-1)
+	for _, item := range batch[1:] {
+		links = append(links, trace.LinkFromContext(item.ctx))
+	}
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		"embedding batch",
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("embedding.documents", len(batch))),
+	)
+	err := b.flush(ctx, batch)
+	tracing.End(span, err)
+	for _, item := range batch {
+		item.done <- err
+	}
+}
+
+func newEmbeddingBatcher(maxSize int, maxWait time.Duration, flush batchFlushFunc) *embeddingBatcher {
+	if maxSize < 1 {
+		maxSize = 1
+	}
+
+	b := &embeddingBatcher{
+		items:   make(chan *batchItem),
+		maxSize: maxSize,
+		maxWait: maxWait,
+		flush:   flush,
+	}
+	go b.run()
+
+	return b
+}
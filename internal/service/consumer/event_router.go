@@ -0,0 +1,113 @@
+package consumer
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+type EventHandler func(ctx context.Context, envelope *evententity.Envelope) error
+
+type IEventRouter interface {
+	Subscribe(name string, handler EventHandler, eventTypes ...string)
+	Handle(ctx context.Context, msg *Message) error
+}
+
+type eventSubscription struct {
+	name    string
+	handler EventHandler
+}
+
+type eventRouter struct {
+	subscriptions map[string][]eventSubscription
+}
+
+func (r *eventRouter) Subscribe(name string, handler EventHandler, eventTypes ...string) {
+	for _, eventType := range eventTypes {
+		r.subscriptions[eventType] = append(r.subscriptions[eventType], eventSubscription{
+			name:    name,
+			handler: handler,
+		})
+	}
+}
+
+// Handle decodes the envelope and runs every subscriber of its type. A failing
+// subscriber fails the whole message, so subscribers must be safe to run again.
+func (r *eventRouter) Handle(ctx context.Context, msg *Message) error {
+	var envelope evententity.Envelope
+	err := json.Unmarshal(msg.Payload, &envelope)
+	if err != nil {
+		return Permanent(err)
+	}
+	// Transports without metadata carry the request id in the envelope only.
+	if logging.RequestId(ctx) == "" && envelope.RequestId != "" {
+		ctx = logging.WithRequestId(ctx, envelope.RequestId)
+	}
+	if envelope.SchemaVersion > evententity.SchemaVersion {
+		return Permanent(fmt.Errorf("unsupported schema version %d for event %s", envelope.SchemaVersion, envelope.Type))
+	}
+
+	subscriptions := make([]eventSubscription, 0)
+	subscriptions = append(subscriptions, r.subscriptions[envelope.Type]...)
+	subscriptions = append(subscriptions, r.subscriptions[AllEvents]...)
+	if len(subscriptions) == 0 {
+		slog.DebugContext(ctx, "No subscriber for event", "event_type", envelope.Type)
+		return nil
+	}
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		err = r.handle(ctx, subscription, &envelope)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", subscription.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *eventRouter) handle(ctx context.Context, subscription eventSubscription, envelope *evententity.Envelope) error {
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		subscription.name+" "+envelope.Type,
+		trace.WithAttributes(
+			attribute.String("event.id", envelope.Id.String()),
+			attribute.String("event.type", envelope.Type),
+		),
+	)
+	err := subscription.handler(ctx, envelope)
+	tracing.End(span, err)
+
+	return err
+}
+
+func NewEventRouter() IEventRouter {
+	return &eventRouter{
+		subscriptions: make(map[string][]eventSubscription),
+	}
+}
+
+// LogEvent is a subscriber keeping an audit trail of every event in the logs.
+func LogEvent(ctx context.Context, envelope *evententity.Envelope) error {
+	slog.InfoContext(
+		ctx,
+		"Event",
+		"event_id", envelope.Id,
+		"event_type", envelope.Type,
+		"actor", envelope.Actor,
+		"occurred_at", envelope.OccurredAt,
+		"data", envelope.Data,
+	)
+	return nil
+}
@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is the transport-agnostic view of a delivery, built by every consumer
+// before handing it to the handler chain.
+type Message struct {
+	Id       string
+	Payload  []byte
+	Metadata map[string]string
+	// Attempt is the delivery attempt as the transport counts it, 0 when it
+	// does not. Retry counts the in process retries of RetryMiddleware on top.
+	Attempt int
+	Retry   int
+}
+
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+type MessageMiddleware func(next MessageHandler) MessageHandler
+
+// ChainMiddleware wraps handler so that the first middleware is the outermost one.
+func ChainMiddleware(handler MessageHandler, middlewares ...MessageMiddleware) MessageHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying, e.g. a payload that can never be decoded.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var target *permanentError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,156 @@
+package consumer
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware processes every message in a consumer span that continues
+// the trace of the publisher, read from the message metadata or, for transports
+// without metadata, from the envelope.
+func TracingMiddleware() MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			ctx = extractTraceContext(ctx, msg)
+			ctx, span := tracing.Tracer().Start(
+				ctx,
+				"process message",
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(attribute.String("messaging.message.id", msg.Id)),
+			)
+			err := next(ctx, msg)
+			tracing.End(span, err)
+
+			return err
+		}
+	}
+}
+
+func extractTraceContext(ctx context.Context, msg *Message) context.Context {
+	traced := tracing.Extract(ctx, msg.Metadata)
+	if trace.SpanContextFromContext(traced).IsValid() {
+		return traced
+	}
+
+	var envelope evententity.Envelope
+	err := json.Unmarshal(msg.Payload, &envelope)
+	if err != nil {
+		return ctx
+	}
+
+	return tracing.Extract(ctx, envelope.TraceContext)
+}
+
+// LoggingMiddleware picks the request id up from the message metadata so the
+// processing of a message logs under the id of the request that caused it.
+func LoggingMiddleware() MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			requestId := msg.Metadata[logging.RequestIdKey]
+			if requestId != "" {
+				ctx = logging.WithRequestId(ctx, requestId)
+			}
+
+			start := time.Now()
+			slog.DebugContext(ctx, "Processing message", "message_id", msg.Id)
+
+			err := next(ctx, msg)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed processing message", "message_id", msg.Id, "duration", time.Since(start), "error", err)
+				return err
+			}
+
+			slog.InfoContext(ctx, "Processed message", "message_id", msg.Id, "duration", time.Since(start))
+			return nil
+		}
+	}
+}
+
+// RetryMiddleware retries failed messages in process with exponential backoff
+// before the transport gets to see the error. Permanent errors are not retried.
+func RetryMiddleware(maxAttempts int, backoff time.Duration) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			var err error
+			wait := backoff
+			for attempt := 1; attempt <= maxAttempts; attempt++ {
+				msg.Retry = attempt - 1
+				err = next(ctx, msg)
+				if err == nil || IsPermanent(err) || attempt == maxAttempts {
+					return err
+				}
+
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(wait):
+				}
+				wait *= 2
+			}
+
+			return err
+		}
+	}
+}
+
+type IMessageMetricsRecorder interface {
+	ObserveMessage(result string, duration time.Duration)
+}
+
+func MetricsMiddleware(recorder IMessageMetricsRecorder) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			result := "success"
+			if err != nil {
+				result = "error"
+			}
+			recorder.ObserveMessage(result, time.Since(start))
+
+			return err
+		}
+	}
+}
+
+type IIdempotencyStore interface {
+	IsProcessed(ctx context.Context, messageId string) (bool, error)
+	MarkProcessed(ctx context.Context, messageId string) error
+}
+
+// IdempotencyMiddleware skips messages whose id was already handled successfully.
+// Messages without an id are always processed.
+func IdempotencyMiddleware(store IIdempotencyStore) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			if msg.Id == "" {
+				return next(ctx, msg)
+			}
+
+			processed, err := store.IsProcessed(ctx, msg.Id)
+			if err != nil {
+				return err
+			}
+			if processed {
+				slog.InfoContext(ctx, "Skipping already processed message", "message_id", msg.Id)
+				return nil
+			}
+
+			err = next(ctx, msg)
+			if err != nil {
+				return err
+			}
+
+			return store.MarkProcessed(ctx, msg.Id)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package consumer
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// noteCoalescer collapses jobs for the same note that are in flight at the same
+// time. Jobs of a note run one at a time and a job that got superseded while
+// waiting is skipped, since the newest job embeds the latest content anyway.
+type noteCoalescer struct {
+	mu    sync.Mutex
+	notes map[uuid.UUID]*coalescedNote
+}
+
+type coalescedNote struct {
+	mu       sync.Mutex
+	latest   uint64
+	refCount int
+}
+
+// Do runs fn unless a newer job for the same note arrived while this one was
+// waiting its turn. Returns whether fn ran.
+func (c *noteCoalescer) Do(noteId uuid.UUID, fn func() error) (bool, error) {
+	c.mu.Lock()
+	note, ok := c.notes[noteId]
+	if !ok {
+		note = &coalescedNote{}
+		c.notes[noteId] = note
+	}
+	note.latest++
+	ticket := note.latest
+	note.refCount++
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		note.refCount--
+		if note.refCount == 0 {
+			delete(c.notes, noteId)
+		}
+		c.mu.Unlock()
+	}()
+
+	note.mu.Lock()
+	defer note.mu.Unlock()
+
+	c.mu.Lock()
+	superseded := ticket != note.latest
+	c.mu.Unlock()
+	if superseded {
+		return false, nil
+	}
+
+	return true, fn()
+}
+
+func newNoteCoalescer() *noteCoalescer {
+	return &noteCoalescer{
+		notes: make(map[uuid.UUID]*coalescedNote),
+	}
+}
@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// noteDebouncer holds back the work for a note that is being edited in quick
+// succession. The work scheduled last runs once the window passes without a
+// newer one for the same note. maxDelay bounds the wait from the first held
+// back schedule, so a note edited without pause still gets its work done.
+// Nothing waits in the caller, the work runs on a timer.
+type noteDebouncer struct {
+	window   time.Duration
+	maxDelay time.Duration
+
+	mu      sync.Mutex
+	notes   map[uuid.UUID]*debouncedNote
+	pending sync.WaitGroup
+}
+
+type debouncedNote struct {
+	first time.Time
+	timer *time.Timer
+	ctx   context.Context
+	work  func(ctx context.Context)
+}
+
+// Schedule replaces the pending work of the note with work, run with ctx
+// once the note settles. ctx is only used for its values, not its deadline.
+func (d *noteDebouncer) Schedule(ctx context.Context, noteId uuid.UUID, work func(ctx context.Context)) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	note, ok := d.notes[noteId]
+	if !ok {
+		note = &debouncedNote{first: now}
+		d.notes[noteId] = note
+		d.pending.Add(1)
+	}
+	note.ctx = context.WithoutCancel(ctx)
+	note.work = work
+
+	delay := d.window
+	if d.maxDelay > 0 {
+		delay = min(delay, time.Until(note.first.Add(d.maxDelay)))
+	}
+	switch {
+	case note.timer == nil:
+		note.timer = time.AfterFunc(delay, func() { d.run(noteId, note) })
+	case note.timer.Stop():
+		note.timer.Reset(delay)
+	}
+	// A timer that could not be stopped already fired, its run picks up work.
+}
+
+func (d *noteDebouncer) run(noteId uuid.UUID, note *debouncedNote) {
+	d.mu.Lock()
+	if d.notes[noteId] != note {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.notes, noteId)
+	d.mu.Unlock()
+
+	defer d.pending.Done()
+	note.work(note.ctx)
+}
+
+// Flush runs the pending work of every note now and waits for it until ctx
+// expires.
+func (d *noteDebouncer) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	for noteId, note := range d.notes {
+		if note.timer.Stop() {
+			go d.run(noteId, note)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return fmt.Errorf("abandoned debounced work of %d notes: %w", len(d.notes), ctx.Err())
+	}
+}
+
+func newNoteDebouncer(window time.Duration, maxDelay time.Duration) *noteDebouncer {
+	return &noteDebouncer{
+		window:   window,
+		maxDelay: maxDelay,
+		notes:    make(map[uuid.UUID]*debouncedNote),
+	}
+}
@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Probe func(ctx context.Context) error
+
+// Check runs its probe at most once per ttl, callers in between get the cached
+// result. Probing the LLM providers costs money and quota, and orchestrators
+// poll readiness every few seconds from every replica.
+type Check struct {
+	name    string
+	probe   Probe
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu   sync.Mutex
+	last *CheckResponse
+	// probing is closed when the running probe finishes, nil when none runs.
+	probing      chan struct{}
+	probeStarted time.Time
+}
+
+// Result waits for at most one probe at a time and no longer than timeout, a
+// probe that ignores its context can not hold up the health routes.
+func (c *Check) Result(ctx context.Context) *CheckResponse {
+	c.mu.Lock()
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.ttl {
+		defer c.mu.Unlock()
+		return c.last
+	}
+	if c.probing == nil {
+		c.probing = make(chan struct{})
+		c.probeStarted = time.Now()
+		go c.run(context.WithoutCancel(ctx), c.probing)
+	}
+	probing, started := c.probing, c.probeStarted
+	c.mu.Unlock()
+
+	wait := time.NewTimer(time.Until(started.Add(c.timeout)))
+	defer wait.Stop()
+	select {
+	case <-probing:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.last
+	case <-wait.C:
+	case <-ctx.Done():
+	}
+
+	slog.WarnContext(ctx, "Health check timed out", "check", c.name, "running_for", time.Since(started))
+	return &CheckResponse{
+		Name:      c.name,
+		Status:    StatusDown,
+		Reason:    failureReason(context.DeadlineExceeded),
+		LatencyMs: time.Since(started).Milliseconds(),
+		CheckedAt: started,
+	}
+}
+
+func (c *Check) run(ctx context.Context, probing chan struct{}) {
+	probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.probe(probeCtx)
+	result := &CheckResponse{
+		Name:      c.name,
+		Status:    StatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Reason = failureReason(err)
+		slog.WarnContext(ctx, "Health check failed", "check", c.name, "error", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = result
+	c.probing = nil
+	close(probing)
+}
+
+// failureReason tells a slow dependency from a failing one without exposing
+// hosts, credentials or queries from the error.
+func failureReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timed out"
+	}
+
+	return "failed"
+}
+
+func NewCheck(name string, probe Probe, ttl time.Duration, timeout time.Duration) *Check {
+	return &Check{
+		name:    name,
+		probe:   probe,
+		ttl:     ttl,
+		timeout: timeout,
+	}
+}
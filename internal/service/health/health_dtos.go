@@ -0,0 +1,43 @@
+package health
+
+import "time"
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckResponse only briefly explains a failure in Reason, the health routes
+// are unauthenticated so the full error goes to the log.
+type CheckResponse struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type ReadinessResponse struct {
+	Status string           `json:"status"`
+	Checks []*CheckResponse `json:"checks"`
+}
+
+type QueueStatusResponse struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Depth is the number of messages waiting to be consumed.
+	Depth     int            `json:"depth"`
+	Statuses  map[string]int `json:"statuses,omitempty"`
+	Consumers *int           `json:"consumers,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+}
+
+type StatusResponse struct {
+	Status    string                 `json:"status"`
+	StartedAt time.Time              `json:"started_at"`
+	Checks    []*CheckResponse       `json:"checks"`
+	Queues    []*QueueStatusResponse `json:"queues"`
+	// EmbeddingBacklog counts the notes still waiting for an up to date embedding.
+	EmbeddingBacklog *int   `json:"embedding_backlog"`
+	BacklogReason    string `json:"backlog_reason,omitempty"`
+}
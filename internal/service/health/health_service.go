@@ -0,0 +1,103 @@
+package health
+
+import (
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type IHealthService interface {
+	Ready(ctx context.Context) *ReadinessResponse
+	Status(ctx context.Context) *StatusResponse
+}
+
+type healthService struct {
+	checks []*Check
+	// queueInspector is nil for the in memory queue, which has nothing to inspect.
+	queueInspector      IQueueInspector
+	queueNames          []string
+	embeddingRepository embeddingrepository.IEmbeddingRepository
+	embeddingModel      string
+
+	startedAt time.Time
+}
+
+// Ready runs every check concurrently, the service is up only when all are.
+func (hs *healthService) Ready(ctx context.Context) *ReadinessResponse {
+	results := make([]*CheckResponse, len(hs.checks))
+	var wg sync.WaitGroup
+	for i, check := range hs.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check.Result(ctx)
+		}()
+	}
+	wg.Wait()
+
+	status := StatusUp
+	for _, result := range results {
+		if result.Status != StatusUp {
+			status = StatusDown
+		}
+	}
+
+	return &ReadinessResponse{
+		Status: status,
+		Checks: results,
+	}
+}
+
+func (hs *healthService) Status(ctx context.Context) *StatusResponse {
+	readiness := hs.Ready(ctx)
+	response := &StatusResponse{
+		Status:    readiness.Status,
+		StartedAt: hs.startedAt,
+		Checks:    readiness.Checks,
+		Queues:    make([]*QueueStatusResponse, 0),
+	}
+
+	if hs.queueInspector != nil {
+		for _, queueName := range hs.queueNames {
+			queue, err := hs.queueInspector.Inspect(ctx, queueName)
+			if err != nil {
+				slog.WarnContext(ctx, "Inspecting queue failed", "queue", queueName, "error", err)
+				queue = &QueueStatusResponse{
+					Name:   queueName,
+					Status: StatusDown,
+					Reason: failureReason(err),
+				}
+			}
+			response.Queues = append(response.Queues, queue)
+		}
+	}
+
+	backlog, err := hs.embeddingRepository.CountStaleNotes(ctx, hs.embeddingModel)
+	if err != nil {
+		slog.WarnContext(ctx, "Counting the embedding backlog failed", "model", hs.embeddingModel, "error", err)
+		response.BacklogReason = failureReason(err)
+	} else {
+		response.EmbeddingBacklog = &backlog
+	}
+
+	return response
+}
+
+func NewHealthService(
+	checks []*Check,
+	queueInspector IQueueInspector,
+	queueNames []string,
+	embeddingRepository embeddingrepository.IEmbeddingRepository,
+	embeddingModel string,
+) IHealthService {
+	return &healthService{
+		checks:              checks,
+		queueInspector:      queueInspector,
+		queueNames:          queueNames,
+		embeddingRepository: embeddingRepository,
+		embeddingModel:      embeddingModel,
+		startedAt:           time.Now(),
+	}
+}
@@ -0,0 +1,83 @@
+package health
+
+import (
+	"ai-notetaking-be/pkg/database"
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/rabbitmq"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func DatabaseProbe(db *pgxpool.Pool) Probe {
+	return db.Ping
+}
+
+func VectorExtensionProbe(db *pgxpool.Pool) Probe {
+	return func(ctx context.Context) error {
+		return database.CheckVectorExtension(ctx, db)
+	}
+}
+
+func RabbitMqProbe(conn *rabbitmq.Connection) Probe {
+	return func(ctx context.Context) error {
+		_, err := conn.Channel()
+		return err
+	}
+}
+
+// EmbeddingProviderProbe embeds a single word, the only call every provider
+// supports that also proves the credentials work.
+func EmbeddingProviderProbe(provider embedding.IEmbeddingProvider) Probe {
+	return func(ctx context.Context) error {
+		_, err := provider.Embed(ctx, "ping", embedding.TaskTypeRetrievalQuery)
+		return err
+	}
+}
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// OllamaProbe lists the models of the ollama server and fails unless all of
+// models are pulled. Listing is free, unlike a generation.
+func OllamaProbe(baseUrl string, models ...string) Probe {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseUrl+"/api/tags", nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("status %d", res.StatusCode)
+		}
+
+		var tags ollamaTagsResponse
+		err = json.NewDecoder(res.Body).Decode(&tags)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(tags.Models))
+		for _, model := range tags.Models {
+			names = append(names, model.Name)
+		}
+		for _, model := range models {
+			// ollama lists untagged models under :latest.
+			if !slices.Contains(names, model) && !slices.Contains(names, model+":latest") {
+				return fmt.Errorf("model %s is not pulled", model)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	jobentity "ai-notetaking-be/internal/entity/job"
+	jobrepository "ai-notetaking-be/internal/repository/job"
+	"ai-notetaking-be/pkg/rabbitmq"
+	"context"
+)
+
+type IQueueInspector interface {
+	Inspect(ctx context.Context, queueName string) (*QueueStatusResponse, error)
+}
+
+type postgresQueueInspector struct {
+	jobRepository jobrepository.IJobRepository
+}
+
+func (i *postgresQueueInspector) Inspect(ctx context.Context, queueName string) (*QueueStatusResponse, error) {
+	statuses, err := i.jobRepository.CountByStatus(ctx, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueStatusResponse{
+		Name:     queueName,
+		Status:   StatusUp,
+		Depth:    statuses[jobentity.StatusPending],
+		Statuses: statuses,
+	}, nil
+}
+
+func NewPostgresQueueInspector(jobRepository jobrepository.IJobRepository) IQueueInspector {
+	return &postgresQueueInspector{
+		jobRepository: jobRepository,
+	}
+}
+
+type rabbitMqQueueInspector struct {
+	conn *rabbitmq.Connection
+}
+
+func (i *rabbitMqQueueInspector) Inspect(ctx context.Context, queueName string) (*QueueStatusResponse, error) {
+	queue, err := i.conn.InspectQueue(queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueStatusResponse{
+		Name:      queueName,
+		Status:    StatusUp,
+		Depth:     queue.Messages,
+		Consumers: &queue.Consumers,
+	}, nil
+}
+
+func NewRabbitMqQueueInspector(conn *rabbitmq.Connection) IQueueInspector {
+	return &rabbitMqQueueInspector{
+		conn: conn,
+	}
+}
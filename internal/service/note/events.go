@@ -0,0 +1,34 @@
+package note
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	publisherservice "ai-notetaking-be/internal/service/publisher"
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+func publishEvent(ctx context.Context, publisherService publisherservice.IPublisherService, eventType string, data any) error {
+	envelope, err := evententity.NewEnvelope(eventType, "System", data)
+	if err != nil {
+		return err
+	}
+	envelope.RequestId = logging.RequestId(ctx)
+	envelope.TraceContext = tracing.Carrier(ctx)
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	err = publisherService.Publish(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", eventType, err)
+	}
+
+	slog.InfoContext(ctx, "Published event", "event_id", envelope.Id, "event_type", eventType)
+	return nil
+}
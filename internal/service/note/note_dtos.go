@@ -7,8 +7,8 @@ import (
 )
 
 type CreateNoteRequest struct {
-	Title      string     `json:"title"`
-	Content    string     `json:"content"`
+	Title      string     `json:"title" validate:"notblank,max=255"`
+	Content    string     `json:"content" validate:"max=100000"`
 	NotebookId *uuid.UUID `json:"notebook_id"`
 }
 
@@ -17,8 +17,8 @@ type CreateNoteResponse struct {
 }
 
 type UpdateNoteRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string `json:"title" validate:"notblank,max=255"`
+	Content string `json:"content" validate:"max=100000"`
 }
 
 type UpdateNoteResponse struct {
@@ -34,7 +34,7 @@ type UpdateNoteNotebookResponse struct {
 }
 
 type SearchNoteRequest struct {
-	Query string `query:"query"`
+	Query string `query:"query" validate:"notblank,max=1000"`
 }
 
 type SearchNoteResponse struct {
@@ -43,13 +43,25 @@ type SearchNoteResponse struct {
 }
 
 type AskNoteRequest struct {
-	Question string `query:"question"`
+	Question string `query:"question" validate:"notblank,max=1000"`
 }
 
 type AskNoteResponse struct {
 	Answer string `json:"answer"`
 }
 
+type RelatedNoteRequest struct {
+	NotebookId *uuid.UUID `query:"notebook_id"`
+	Limit      int        `query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+type RelatedNoteResponse struct {
+	Id         uuid.UUID  `json:"id"`
+	Title      string     `json:"title"`
+	NotebookId *uuid.UUID `json:"notebook_id"`
+	Similarity float64    `json:"similarity"`
+}
+
 type ShowNoteResponse struct {
 	Id         uuid.UUID  `json:"id"`
 	Title      string     `json:"title"`
@@ -60,8 +72,3 @@ type ShowNoteResponse struct {
 	UpdatedAt  *time.Time `json:"updated_at"`
 	UpdatedBy  *string    `json:"updated_by"`
 }
-
-type EmbedCreatedNoteMessage struct {
-	NoteId             uuid.UUID `json:"note_id"`
-	DeleteOldEmbedding bool      `json:"delete_old_embedding"`
-}
@@ -1,33 +1,33 @@
 package note
 
 import (
+	"ai-notetaking-be/internal/config"
+	evententity "ai-notetaking-be/internal/entity/event"
 	noteentity "ai-notetaking-be/internal/entity/note"
 	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
 	noterepository "ai-notetaking-be/internal/repository/note"
 	publisherservice "ai-notetaking-be/internal/service/publisher"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/database"
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/tracing"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type EmbeddingModelRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-}
-
-type EmbeddingModelResponse struct {
-	Embedding []float32 `json:"embedding"`
-}
-
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -53,10 +53,18 @@ type ChatResponse struct {
 	EvalDuration       int64       `json:"eval_duration"`
 }
 
+// INoteMetricsRecorder receives the latency of the vector search and of the chat
+// model call, together with the token counts ollama reports for the latter.
+type INoteMetricsRecorder interface {
+	ObserveVectorSearch(err error, latency time.Duration)
+	ObserveChat(model string, response *ChatResponse, err error, latency time.Duration)
+}
+
 type INoteService interface {
 	Create(ctx context.Context, request *CreateNoteRequest) (*CreateNoteResponse, error)
 	Search(ctx context.Context, request *SearchNoteRequest) ([]*SearchNoteResponse, error)
 	Ask(ctx context.Context, request *AskNoteRequest) (*AskNoteResponse, error)
+	Related(ctx context.Context, id uuid.UUID, request *RelatedNoteRequest) ([]*RelatedNoteResponse, error)
 	Update(ctx context.Context, id uuid.UUID, request *UpdateNoteRequest) (*UpdateNoteResponse, error)
 	UpdateNoteNotebook(ctx context.Context, id uuid.UUID, request *UpdateNoteNotebookRequest) (*UpdateNoteNotebookResponse, error)
 	Delete(ctx context.Context, id uuid.UUID) error
@@ -65,16 +73,25 @@ type INoteService interface {
 
 type noteService struct {
 	noteRepository      noterepository.INoteRepository
+	notebookRepository  noterepository.INotebookRepository
 	embeddingRepository embeddingrepository.IEmbeddingRepository
 	publisherService    publisherservice.IPublisherService
+	metricsRecorder     INoteMetricsRecorder
+	// embeddingProvider embeds queries, it must use the model of the stored
+	// embeddings.
+	embeddingProvider embedding.IEmbeddingProvider
 
-	embeddingModelName      string
-	embeddingServiceBaseUrl string
+	ollamaConfig config.OllamaConfig
 
-	db *pgxpool.Pool
+	db database.DatabaseTxBeginner
 }
 
 func (ns *noteService) Create(ctx context.Context, request *CreateNoteRequest) (*CreateNoteResponse, error) {
+	err := ensureNotebookExists(ctx, ns.notebookRepository, request.NotebookId, "notebook_id")
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.New()
 	noteEntity := noteentity.Note{
 		Id:         id,
@@ -84,50 +101,29 @@ func (ns *noteService) Create(ctx context.Context, request *CreateNoteRequest) (
 		CreatedAt:  time.Now(),
 		CreatedBy:  "System",
 	}
-	err := ns.noteRepository.Create(ctx, &noteEntity)
+	err = ns.noteRepository.Create(ctx, &noteEntity)
 	if err != nil {
 		return nil, err
 	}
 
-	msg := EmbedCreatedNoteMessage{
-		NoteId: id,
-	}
-	msgJson, err := json.Marshal(msg)
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNoteCreated, evententity.NoteCreated{
+		NoteId:     id,
+		NotebookId: request.NotebookId,
+	})
 	if err != nil {
-		return nil, err
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
 	}
 
-	ns.publisherService.Publish(
-		ctx,
-		msgJson,
-	)
-
 	return &CreateNoteResponse{Id: id}, nil
 }
 
 func (ns *noteService) Search(ctx context.Context, request *SearchNoteRequest) ([]*SearchNoteResponse, error) {
-	req := EmbeddingModelRequest{
-		Model:  ns.embeddingModelName,
-		Prompt: request.Query,
-	}
-	reqJson, _ := json.Marshal(req)
-	res, err := http.Post(fmt.Sprintf("%s/api/embeddings", ns.embeddingServiceBaseUrl), "application/json", bytes.NewBuffer(reqJson))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-
-	var embeddingResponse EmbeddingModelResponse
-	err = json.NewDecoder(res.Body).Decode(&embeddingResponse)
+	queryEmbedding, err := ns.embedQuery(ctx, request.Query)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 
-	ids, err := ns.embeddingRepository.FindMostSimilarNoteIds(
-		ctx,
-		embeddingResponse.Embedding,
-	)
+	ids, err := ns.findMostSimilarNoteIds(ctx, queryEmbedding)
 	if err != nil {
 		return nil, err
 	}
@@ -152,28 +148,12 @@ func (ns *noteService) Search(ctx context.Context, request *SearchNoteRequest) (
 }
 
 func (ns *noteService) Ask(ctx context.Context, request *AskNoteRequest) (*AskNoteResponse, error) {
-	req := EmbeddingModelRequest{
-		Model:  ns.embeddingModelName,
-		Prompt: request.Question,
-	}
-	reqJson, _ := json.Marshal(req)
-	res, err := http.Post(fmt.Sprintf("%s/api/embeddings", ns.embeddingServiceBaseUrl), "application/json", bytes.NewBuffer(reqJson))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-
-	var embeddingResponse EmbeddingModelResponse
-	err = json.NewDecoder(res.Body).Decode(&embeddingResponse)
+	queryEmbedding, err := ns.embedQuery(ctx, request.Question)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 
-	ids, err := ns.embeddingRepository.FindMostSimilarNoteIds(
-		ctx,
-		embeddingResponse.Embedding,
-	)
+	ids, err := ns.findMostSimilarNoteIds(ctx, queryEmbedding)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +183,7 @@ func (ns *noteService) Ask(ctx context.Context, request *AskNoteRequest) (*AskNo
 	`, referencesString, request.Question)
 
 	chatRequest := ChatRequest{
-		Model: "llama3.2",
+		Model: ns.ollamaConfig.ChatModel,
 		Messages: []ChatMessage{
 			{
 				Role:    "user",
@@ -212,23 +192,162 @@ func (ns *noteService) Ask(ctx context.Context, request *AskNoteRequest) (*AskNo
 		},
 		Stream: false,
 	}
-	chatRequestJson, _ := json.Marshal(&chatRequest)
-	res, err = http.Post("http://localhost:11434/api/chat", "application/json", bytes.NewBuffer(chatRequestJson))
+	start := time.Now()
+	answerResponse, err := ns.chat(ctx, &chatRequest)
+	ns.metricsRecorder.ObserveChat(chatRequest.Model, answerResponse, err, time.Since(start))
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 
-	var answerResponse ChatResponse
-	err = json.NewDecoder(res.Body).Decode(&answerResponse)
+	return &AskNoteResponse{
+		Answer: answerResponse.Message.Content,
+	}, nil
+}
+
+// Related searches with the newest stored embedding of the note, among the
+// embeddings of the same model only. A note that is not embedded yet has no
+// related notes.
+func (ns *noteService) Related(ctx context.Context, id uuid.UUID, request *RelatedNoteRequest) ([]*RelatedNoteResponse, error) {
+	_, err := ns.noteRepository.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = ensureNotebookExists(ctx, ns.notebookRepository, request.NotebookId, "notebook_id")
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 
-	return &AskNoteResponse{
-		Answer: answerResponse.Message.Content,
-	}, nil
+	embeddings, err := ns.embeddingRepository.GetByNoteId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]*RelatedNoteResponse, 0)
+	if len(embeddings) == 0 {
+		return response, nil
+	}
+
+	ctx, span := tracing.Tracer().Start(ctx, "vector search")
+	start := time.Now()
+	similar, err := ns.embeddingRepository.SearchNearest(ctx, embeddings[0].Embedding, embeddingrepository.SearchOptions{
+		Limit:         request.Limit,
+		ExcludeNoteId: &id,
+		NotebookId:    request.NotebookId,
+		Model:         embeddings[0].Model,
+	})
+	ns.metricsRecorder.ObserveVectorSearch(err, time.Since(start))
+	span.SetAttributes(attribute.Int("vector_search.results", len(similar)))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(similar))
+	for _, note := range similar {
+		ids = append(ids, note.NoteId)
+	}
+	notes, err := ns.noteRepository.GetByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	notesById := make(map[uuid.UUID]*noteentity.Note, len(notes))
+	for _, note := range notes {
+		notesById[note.Id] = note
+	}
+
+	// GetByIds does not keep the order of ids, the ranking comes from similar.
+	for _, match := range similar {
+		note, ok := notesById[match.NoteId]
+		if !ok {
+			continue
+		}
+		response = append(response, &RelatedNoteResponse{
+			Id:         note.Id,
+			Title:      note.Title,
+			NotebookId: note.NotebookId,
+			Similarity: match.Similarity,
+		})
+	}
+
+	return response, nil
+}
+
+func (ns *noteService) embedQuery(ctx context.Context, query string) (values []float32, err error) {
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		"embed query",
+		trace.WithAttributes(attribute.String("embedding.model", ns.embeddingProvider.Model())),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	values, err = ns.embeddingProvider.Embed(ctx, query, embedding.TaskTypeRetrievalQuery)
+	if err != nil {
+		return nil, apperror.Unavailable("embedding server is unavailable", err)
+	}
+
+	return values, nil
+}
+
+func (ns *noteService) findMostSimilarNoteIds(ctx context.Context, embedding []float32) ([]uuid.UUID, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "vector search")
+	start := time.Now()
+	ids, err := ns.embeddingRepository.FindMostSimilarNoteIds(ctx, embedding)
+	ns.metricsRecorder.ObserveVectorSearch(err, time.Since(start))
+	span.SetAttributes(attribute.Int("vector_search.results", len(ids)))
+	tracing.End(span, err)
+
+	return ids, err
+}
+
+func (ns *noteService) chat(ctx context.Context, request *ChatRequest) (response *ChatResponse, err error) {
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		"chat",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("chat.model", request.Model)),
+	)
+	defer func() {
+		if response != nil {
+			span.SetAttributes(
+				attribute.Int("chat.prompt_tokens", response.PromptEvalCount),
+				attribute.Int("chat.eval_tokens", response.EvalCount),
+				attribute.Int64("chat.load_duration_ns", response.LoadDuration),
+				attribute.Int64("chat.prompt_eval_duration_ns", response.PromptEvalDuration),
+				attribute.Int64("chat.eval_duration_ns", response.EvalDuration),
+			)
+		}
+		tracing.End(span, err)
+	}()
+
+	requestJson, _ := json.Marshal(request)
+	res, err := ns.post(ctx, "/api/chat", requestJson)
+	if err != nil {
+		return nil, apperror.Unavailable("chat model is unavailable", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, apperror.Unavailable("chat model is unavailable", fmt.Errorf("status %d", res.StatusCode))
+	}
+
+	var chatResponse ChatResponse
+	err = json.NewDecoder(res.Body).Decode(&chatResponse)
+	if err != nil {
+		return nil, apperror.Unavailable("chat model returned an invalid response", err)
+	}
+
+	return &chatResponse, nil
+}
+
+// post sends body to the ollama endpoint at path, bound to ctx so the call is
+// cancelled with the request and shows up in its trace.
+func (ns *noteService) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ns.ollamaConfig.BaseUrl+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return http.DefaultClient.Do(req)
 }
 
 func (ns *noteService) Update(ctx context.Context, id uuid.UUID, request *UpdateNoteRequest) (*UpdateNoteResponse, error) {
@@ -248,46 +367,40 @@ func (ns *noteService) Update(ctx context.Context, id uuid.UUID, request *Update
 		return nil, err
 	}
 
-	msg := EmbedCreatedNoteMessage{
-		NoteId:             id,
-		DeleteOldEmbedding: true,
-	}
-	msgJson, err := json.Marshal(msg)
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNoteUpdated, evententity.NoteUpdated{
+		NoteId: id,
+	})
 	if err != nil {
-		return nil, err
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
 	}
 
-	ns.publisherService.Publish(
-		ctx,
-		msgJson,
-	)
-
 	return &UpdateNoteResponse{Id: id}, nil
 }
 
 func (ns *noteService) UpdateNoteNotebook(ctx context.Context, id uuid.UUID, request *UpdateNoteNotebookRequest) (*UpdateNoteNotebookResponse, error) {
-	_, err := ns.noteRepository.GetById(ctx, id)
+	n, err := ns.noteRepository.GetById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = ns.noteRepository.UpdateNoteNotebook(ctx, id, request.NewNotebookId, "System")
+	err = ensureNotebookExists(ctx, ns.notebookRepository, request.NewNotebookId, "new_notebook_id")
 	if err != nil {
 		return nil, err
 	}
 
-	msg := EmbedCreatedNoteMessage{
-		NoteId:             id,
-		DeleteOldEmbedding: true,
-	}
-	msgJson, err := json.Marshal(msg)
+	err = ns.noteRepository.UpdateNoteNotebook(ctx, id, request.NewNotebookId, "System")
 	if err != nil {
 		return nil, err
 	}
-	ns.publisherService.Publish(
-		ctx,
-		msgJson,
-	)
+
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNoteMoved, evententity.NoteMoved{
+		NoteId:         id,
+		FromNotebookId: n.NotebookId,
+		ToNotebookId:   request.NewNotebookId,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
+	}
 
 	return &UpdateNoteNotebookResponse{Id: id}, nil
 }
@@ -321,7 +434,14 @@ func (ns *noteService) Delete(ctx context.Context, id uuid.UUID) error {
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNoteDeleted, evententity.NoteDeleted{
+		NoteId: id,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
 	}
 
 	return nil
@@ -348,18 +468,22 @@ func (ns *noteService) Show(ctx context.Context, id uuid.UUID) (*ShowNoteRespons
 
 func NewNoteService(
 	noteRepository noterepository.INoteRepository,
+	notebookRepository noterepository.INotebookRepository,
 	embeddingRepository embeddingrepository.IEmbeddingRepository,
 	publisherService publisherservice.IPublisherService,
-	embeddingServiceBaseUrl string,
-	embeddingModelName string,
-	db *pgxpool.Pool,
+	metricsRecorder INoteMetricsRecorder,
+	embeddingProvider embedding.IEmbeddingProvider,
+	ollamaConfig config.OllamaConfig,
+	db database.DatabaseTxBeginner,
 ) INoteService {
 	return &noteService{
-		noteRepository:          noteRepository,
-		publisherService:        publisherService,
-		embeddingRepository:     embeddingRepository,
-		embeddingModelName:      embeddingModelName,
-		embeddingServiceBaseUrl: embeddingServiceBaseUrl,
-		db:                      db,
+		noteRepository:      noteRepository,
+		notebookRepository:  notebookRepository,
+		publisherService:    publisherService,
+		embeddingRepository: embeddingRepository,
+		metricsRecorder:     metricsRecorder,
+		embeddingProvider:   embeddingProvider,
+		ollamaConfig:        ollamaConfig,
+		db:                  db,
 	}
 }
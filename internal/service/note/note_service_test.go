@@ -0,0 +1,484 @@
+package note_test
+
+import (
+	"ai-notetaking-be/internal/config"
+	embeddingentity "ai-notetaking-be/internal/entity/embedding"
+	evententity "ai-notetaking-be/internal/entity/event"
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"ai-notetaking-be/internal/repository/memory"
+	noterepository "ai-notetaking-be/internal/repository/note"
+	noteservice "ai-notetaking-be/internal/service/note"
+	publisherservice "ai-notetaking-be/internal/service/publisher"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/fakellm"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type nopMetricsRecorder struct{}
+
+func (nopMetricsRecorder) ObserveVectorSearch(err error, latency time.Duration) {}
+
+func (nopMetricsRecorder) ObserveChat(model string, response *noteservice.ChatResponse, err error, latency time.Duration) {
+}
+
+type fixture struct {
+	ctx        context.Context
+	notes      noterepository.INoteRepository
+	notebooks  noterepository.INotebookRepository
+	embeddings embeddingrepository.IEmbeddingRepository
+	publisher  *publisherservice.FakePublisherService
+	llm        *fakellm.Server
+	// queryEmbeddings are what the llm embeds these texts to.
+	queryEmbeddings map[string][]float32
+	noteService     noteservice.INoteService
+	notebookService noteservice.INotebookService
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	store := memory.NewStore()
+	f := &fixture{
+		ctx:        context.Background(),
+		notes:      memory.NewMemoryNoteRepository(store),
+		notebooks:  memory.NewMemoryNotebookRepository(store),
+		embeddings: memory.NewMemoryEmbeddingRepository(store, embeddingrepository.MetricL2),
+		publisher:  publisherservice.NewFakePublisherService(),
+	}
+	f.queryEmbeddings = make(map[string][]float32)
+	f.llm = fakellm.New(fakellm.Options{
+		Embed: func(text string) []float32 {
+			if embedding, ok := f.queryEmbeddings[text]; ok {
+				return embedding
+			}
+			return fakellm.Embed(text, 2)
+		},
+		Reply: func(messages []fakellm.ChatMessage) string {
+			return "the answer"
+		},
+	})
+	server := f.llm.Start()
+	t.Cleanup(server.Close)
+
+	ollamaConfig := config.OllamaConfig{
+		BaseUrl:        server.URL,
+		EmbeddingModel: "embed-model",
+		ChatModel:      "chat-model",
+	}
+	queryEmbeddingProvider := embedding.NewCachedProvider(
+		embedding.NewOllamaProvider(server.URL, ollamaConfig.EmbeddingModel),
+		embedding.CacheOptions{Size: 100, TTL: time.Hour},
+	)
+	f.noteService = noteservice.NewNoteService(f.notes, f.notebooks, f.embeddings, f.publisher, nopMetricsRecorder{}, queryEmbeddingProvider, ollamaConfig, store)
+	f.notebookService = noteservice.NewNotebookService(f.notebooks, f.notes, f.embeddings, f.publisher, store)
+
+	return f
+}
+
+func (f *fixture) createNotebook(t *testing.T, name string, parentId *uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	res, err := f.notebookService.Create(f.ctx, &noteservice.CreateNotebookRequest{Name: name, ParentId: parentId})
+	if err != nil {
+		t.Fatalf("create notebook: %v", err)
+	}
+
+	return res.Id
+}
+
+func (f *fixture) createNote(t *testing.T, title string, content string, notebookId *uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	res, err := f.noteService.Create(f.ctx, &noteservice.CreateNoteRequest{Title: title, Content: content, NotebookId: notebookId})
+	if err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+
+	return res.Id
+}
+
+func (f *fixture) embed(t *testing.T, noteId uuid.UUID, embedding []float32) {
+	t.Helper()
+
+	err := f.embeddings.CreateNoteEmbedding(f.ctx, &embeddingentity.NoteEmbedding{
+		Id:        uuid.New(),
+		NoteId:    noteId,
+		Model:     "embed-model",
+		Embedding: embedding,
+		CreatedAt: time.Now(),
+		CreatedBy: "System",
+	})
+	if err != nil {
+		t.Fatalf("create embedding: %v", err)
+	}
+}
+
+// events returns the published envelopes after checking their types.
+func (f *fixture) events(t *testing.T, types ...string) []evententity.Envelope {
+	t.Helper()
+
+	envelopes, err := f.publisher.Envelopes()
+	if err != nil {
+		t.Fatalf("decode envelopes: %v", err)
+	}
+	published := make([]string, 0)
+	for _, envelope := range envelopes {
+		published = append(published, envelope.Type)
+	}
+	if !slices.Equal(published, types) {
+		t.Fatalf("published %v, want %v", published, types)
+	}
+
+	return envelopes
+}
+
+func decodeData[T any](t *testing.T, envelope evententity.Envelope) T {
+	t.Helper()
+
+	var data T
+	err := json.Unmarshal(envelope.Data, &data)
+	if err != nil {
+		t.Fatalf("decode %s data: %v", envelope.Type, err)
+	}
+
+	return data
+}
+
+func assertKind(t *testing.T, err error, kind apperror.Kind) {
+	t.Helper()
+
+	if !apperror.Is(err, kind) {
+		t.Fatalf("error = %v, want kind %s", err, kind)
+	}
+}
+
+func TestNoteServiceCreate(t *testing.T) {
+	f := newFixture(t)
+	notebookId := f.createNotebook(t, "Work", nil)
+
+	id := f.createNote(t, "Standup", "Notes of the standup", &notebookId)
+
+	note, err := f.noteService.Show(f.ctx, id)
+	if err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	if note.Title != "Standup" || note.Content != "Notes of the standup" || *note.NotebookId != notebookId {
+		t.Fatalf("unexpected note %+v", note)
+	}
+	if note.CreatedBy != "System" || note.UpdatedAt != nil {
+		t.Fatalf("unexpected audit fields %+v", note)
+	}
+
+	envelopes := f.events(t, evententity.TypeNoteCreated)
+	created := decodeData[evententity.NoteCreated](t, envelopes[0])
+	if created.NoteId != id || *created.NotebookId != notebookId {
+		t.Fatalf("unexpected event data %+v", created)
+	}
+}
+
+func TestNoteServiceCreateWithMissingNotebook(t *testing.T) {
+	f := newFixture(t)
+	notebookId := uuid.New()
+
+	_, err := f.noteService.Create(f.ctx, &noteservice.CreateNoteRequest{Title: "Orphan", NotebookId: &notebookId})
+
+	assertKind(t, err, apperror.KindValidation)
+	f.events(t)
+}
+
+func TestNoteServiceCreateSurvivesPublishFailure(t *testing.T) {
+	f := newFixture(t)
+	f.publisher.Err = context.DeadlineExceeded
+
+	id := f.createNote(t, "Loose", "", nil)
+
+	_, err := f.noteService.Show(f.ctx, id)
+	if err != nil {
+		t.Fatalf("note was not stored: %v", err)
+	}
+}
+
+func TestNoteServiceShowMissing(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.noteService.Show(f.ctx, uuid.New())
+
+	assertKind(t, err, apperror.KindNotFound)
+}
+
+func TestNoteServiceUpdate(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNote(t, "Draft", "first", nil)
+	f.publisher.Reset()
+
+	_, err := f.noteService.Update(f.ctx, id, &noteservice.UpdateNoteRequest{Title: "Final", Content: "second"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	note, _ := f.noteService.Show(f.ctx, id)
+	if note.Title != "Final" || note.Content != "second" {
+		t.Fatalf("unexpected note %+v", note)
+	}
+	if note.UpdatedAt == nil || *note.UpdatedBy != "System" {
+		t.Fatalf("update was not audited %+v", note)
+	}
+	envelopes := f.events(t, evententity.TypeNoteUpdated)
+	if decodeData[evententity.NoteUpdated](t, envelopes[0]).NoteId != id {
+		t.Fatal("event is not about the updated note")
+	}
+}
+
+func TestNoteServiceUpdateMissing(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.noteService.Update(f.ctx, uuid.New(), &noteservice.UpdateNoteRequest{Title: "Nothing"})
+
+	assertKind(t, err, apperror.KindNotFound)
+	f.events(t)
+}
+
+func TestNoteServiceUpdateNoteNotebook(t *testing.T) {
+	f := newFixture(t)
+	fromId := f.createNotebook(t, "Inbox", nil)
+	toId := f.createNotebook(t, "Archive", nil)
+	id := f.createNote(t, "Receipt", "", &fromId)
+	f.publisher.Reset()
+
+	_, err := f.noteService.UpdateNoteNotebook(f.ctx, id, &noteservice.UpdateNoteNotebookRequest{NewNotebookId: &toId})
+	if err != nil {
+		t.Fatalf("move: %v", err)
+	}
+
+	note, _ := f.noteService.Show(f.ctx, id)
+	if *note.NotebookId != toId {
+		t.Fatalf("note is in %s, want %s", note.NotebookId, toId)
+	}
+	envelopes := f.events(t, evententity.TypeNoteMoved)
+	moved := decodeData[evententity.NoteMoved](t, envelopes[0])
+	if *moved.FromNotebookId != fromId || *moved.ToNotebookId != toId {
+		t.Fatalf("unexpected event data %+v", moved)
+	}
+}
+
+func TestNoteServiceUpdateNoteNotebookToMissingNotebook(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNote(t, "Receipt", "", nil)
+	f.publisher.Reset()
+	missingId := uuid.New()
+
+	_, err := f.noteService.UpdateNoteNotebook(f.ctx, id, &noteservice.UpdateNoteNotebookRequest{NewNotebookId: &missingId})
+
+	assertKind(t, err, apperror.KindValidation)
+	note, _ := f.noteService.Show(f.ctx, id)
+	if note.NotebookId != nil {
+		t.Fatal("note was moved")
+	}
+	f.events(t)
+}
+
+func TestNoteServiceDelete(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNote(t, "Secret", "", nil)
+	keptId := f.createNote(t, "Public", "", nil)
+	f.embed(t, id, []float32{1, 0})
+	f.embed(t, keptId, []float32{0, 1})
+	f.publisher.Reset()
+
+	err := f.noteService.Delete(f.ctx, id)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = f.noteService.Show(f.ctx, id)
+	assertKind(t, err, apperror.KindNotFound)
+	ids, _ := f.embeddings.FindMostSimilarNoteIds(f.ctx, []float32{1, 0})
+	if !slices.Equal(ids, []uuid.UUID{keptId}) {
+		t.Fatalf("embeddings of the deleted note are still searched: %v", ids)
+	}
+	envelopes := f.events(t, evententity.TypeNoteDeleted)
+	if decodeData[evententity.NoteDeleted](t, envelopes[0]).NoteId != id {
+		t.Fatal("event is not about the deleted note")
+	}
+}
+
+func TestNoteServiceDeleteMissing(t *testing.T) {
+	f := newFixture(t)
+
+	err := f.noteService.Delete(f.ctx, uuid.New())
+
+	assertKind(t, err, apperror.KindNotFound)
+	f.events(t)
+}
+
+func TestNoteServiceSearch(t *testing.T) {
+	f := newFixture(t)
+	ids := make([]uuid.UUID, 0)
+	for i := range 7 {
+		id := f.createNote(t, string(rune('A'+i)), "", nil)
+		f.embed(t, id, []float32{float32(i), 0})
+		ids = append(ids, id)
+	}
+	f.queryEmbeddings["letters"] = []float32{6, 0}
+
+	res, err := f.noteService.Search(f.ctx, &noteservice.SearchNoteRequest{Query: "letters"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	titles := make([]string, 0)
+	for _, note := range res {
+		titles = append(titles, note.Title)
+	}
+	if !slices.Equal(titles, []string{"G", "F", "E", "D", "C"}) {
+		t.Fatalf("search returned %v, want the five closest notes closest first", titles)
+	}
+}
+
+func TestNoteServiceSearchReusesQueryEmbedding(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNote(t, "Wifi", "", nil)
+	f.embed(t, id, []float32{1, 1})
+
+	for _, query := range []string{"wifi password", "  wifi   password "} {
+		_, err := f.noteService.Search(f.ctx, &noteservice.SearchNoteRequest{Query: query})
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+	}
+
+	if n := len(f.llm.Requests(fakellm.EndpointEmbeddings)); n != 1 {
+		t.Fatalf("embedded the query %d times, want once", n)
+	}
+}
+
+func TestNoteServiceRelated(t *testing.T) {
+	f := newFixture(t)
+	source := f.createNote(t, "Source", "", nil)
+	f.embed(t, source, []float32{0, 0})
+	for i, title := range []string{"Near", "Middle", "Far"} {
+		id := f.createNote(t, title, "", nil)
+		f.embed(t, id, []float32{float32(i + 1), 0})
+	}
+	other := f.createNote(t, "Other model", "", nil)
+	err := f.embeddings.CreateNoteEmbedding(f.ctx, &embeddingentity.NoteEmbedding{
+		Id:        uuid.New(),
+		NoteId:    other,
+		Model:     "other-model",
+		Embedding: []float32{0, 0},
+		CreatedAt: time.Now(),
+		CreatedBy: "System",
+	})
+	if err != nil {
+		t.Fatalf("create embedding: %v", err)
+	}
+
+	res, err := f.noteService.Related(f.ctx, source, &noteservice.RelatedNoteRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("related: %v", err)
+	}
+
+	if len(res) != 2 || res[0].Title != "Near" || res[1].Title != "Middle" {
+		t.Fatalf("related returned %+v, want Near and Middle", res)
+	}
+	// l2 distances 1 and 2.
+	if res[0].Similarity != 0.5 || res[1].Similarity != 1.0/3 {
+		t.Fatalf("similarities %v and %v", res[0].Similarity, res[1].Similarity)
+	}
+}
+
+func TestNoteServiceRelatedInNotebook(t *testing.T) {
+	f := newFixture(t)
+	notebookId := f.createNotebook(t, "Work", nil)
+	source := f.createNote(t, "Source", "", nil)
+	f.embed(t, source, []float32{0, 0})
+	outside := f.createNote(t, "Outside", "", nil)
+	f.embed(t, outside, []float32{1, 0})
+	inside := f.createNote(t, "Inside", "", &notebookId)
+	f.embed(t, inside, []float32{5, 0})
+
+	res, err := f.noteService.Related(f.ctx, source, &noteservice.RelatedNoteRequest{NotebookId: &notebookId})
+	if err != nil {
+		t.Fatalf("related: %v", err)
+	}
+
+	if len(res) != 1 || res[0].Id != inside || *res[0].NotebookId != notebookId {
+		t.Fatalf("related returned %+v, want only the note in the notebook", res)
+	}
+}
+
+func TestNoteServiceRelatedBeforeEmbedding(t *testing.T) {
+	f := newFixture(t)
+	source := f.createNote(t, "Source", "", nil)
+	f.embed(t, f.createNote(t, "Other", "", nil), []float32{1, 0})
+
+	res, err := f.noteService.Related(f.ctx, source, &noteservice.RelatedNoteRequest{})
+	if err != nil {
+		t.Fatalf("related: %v", err)
+	}
+
+	if len(res) != 0 {
+		t.Fatalf("related returned %+v for a note without embeddings", res)
+	}
+}
+
+func TestNoteServiceRelatedMissing(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.noteService.Related(f.ctx, uuid.New(), &noteservice.RelatedNoteRequest{})
+	assertKind(t, err, apperror.KindNotFound)
+
+	missingNotebook := uuid.New()
+	source := f.createNote(t, "Source", "", nil)
+	_, err = f.noteService.Related(f.ctx, source, &noteservice.RelatedNoteRequest{NotebookId: &missingNotebook})
+	assertKind(t, err, apperror.KindValidation)
+}
+
+func TestNoteServiceSearchEmbeddingServerDown(t *testing.T) {
+	f := newFixture(t)
+	f.llm.AddFault(fakellm.Fault{Endpoint: fakellm.EndpointEmbeddings, Status: http.StatusServiceUnavailable})
+
+	_, err := f.noteService.Search(f.ctx, &noteservice.SearchNoteRequest{Query: "anything"})
+
+	assertKind(t, err, apperror.KindUnavailable)
+}
+
+func TestNoteServiceAsk(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNote(t, "Wifi", "The password is hunter2", nil)
+	f.embed(t, id, []float32{1, 1})
+	f.queryEmbeddings["What is the wifi password?"] = []float32{1, 1}
+
+	res, err := f.noteService.Ask(f.ctx, &noteservice.AskNoteRequest{Question: "What is the wifi password?"})
+	if err != nil {
+		t.Fatalf("ask: %v", err)
+	}
+
+	if res.Answer != "the answer" {
+		t.Fatalf("answer = %q", res.Answer)
+	}
+	bodies := f.llm.Requests(fakellm.EndpointChat)
+	if len(bodies) != 1 {
+		t.Fatalf("chat was called %d times", len(bodies))
+	}
+	var request noteservice.ChatRequest
+	json.Unmarshal(bodies[0], &request)
+	if request.Model != "chat-model" || request.Stream {
+		t.Fatalf("unexpected chat request %+v", request)
+	}
+	prompt := request.Messages[0].Content
+	for _, want := range []string{"Reference 1", "Wifi", "The password is hunter2", "What is the wifi password?"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+}
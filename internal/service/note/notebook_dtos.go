@@ -7,7 +7,7 @@ import (
 )
 
 type CreateNotebookRequest struct {
-	Name     string     `json:"name"`
+	Name     string     `json:"name" validate:"notblank,max=255"`
 	ParentId *uuid.UUID `json:"parent_id"`
 }
 
@@ -16,7 +16,7 @@ type CreateNotebookResponse struct {
 }
 
 type UpdateNotebookRequest struct {
-	Name string `json:"name"`
+	Name string `json:"name" validate:"notblank,max=255"`
 }
 
 type UpdateNotebookResponse struct {
@@ -24,7 +24,7 @@ type UpdateNotebookResponse struct {
 }
 
 type UpdateNotebookParentRequest struct {
-	ParentId uuid.UUID `json:"parent_id"`
+	ParentId uuid.UUID `json:"parent_id" validate:"required"`
 }
 
 type UpdateNotebookParentResponse struct {
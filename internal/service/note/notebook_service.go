@@ -1,17 +1,19 @@
 package note
 
 import (
+	evententity "ai-notetaking-be/internal/entity/event"
 	noteentity "ai-notetaking-be/internal/entity/note"
 	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
 	noterepository "ai-notetaking-be/internal/repository/note"
 	publisherservice "ai-notetaking-be/internal/service/publisher"
+	"ai-notetaking-be/pkg/apperror"
+	"ai-notetaking-be/pkg/database"
 	"context"
-	"encoding/json"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type INotebookService interface {
@@ -29,10 +31,15 @@ type notebookService struct {
 	embeddingRepository embeddingrepository.IEmbeddingRepository
 	publisherService    publisherservice.IPublisherService
 
-	db *pgxpool.Pool
+	db database.DatabaseTxBeginner
 }
 
 func (ns *notebookService) Create(ctx context.Context, request *CreateNotebookRequest) (*CreateNotebookResponse, error) {
+	err := ensureNotebookExists(ctx, ns.notebookRepository, request.ParentId, "parent_id")
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.New()
 	notebookEntity := noteentity.Notebook{
 		Id:        id,
@@ -41,7 +48,7 @@ func (ns *notebookService) Create(ctx context.Context, request *CreateNotebookRe
 		CreatedAt: time.Now(),
 		CreatedBy: "System",
 	}
-	err := ns.notebookRepository.Create(ctx, &notebookEntity)
+	err = ns.notebookRepository.Create(ctx, &notebookEntity)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +63,7 @@ func (ns *notebookService) Update(ctx context.Context, id uuid.UUID, request *Up
 	}
 	now := time.Now()
 	updatedBy := "System"
+	oldName := notebook.Name
 	notebook.Name = request.Name
 	notebook.UpdatedAt = &now
 	notebook.UpdatedBy = &updatedBy
@@ -65,41 +73,38 @@ func (ns *notebookService) Update(ctx context.Context, id uuid.UUID, request *Up
 		return nil, err
 	}
 
-	notes, err := ns.noteRepository.GetByNotebookId(ctx, notebook.Id)
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNotebookRenamed, evententity.NotebookRenamed{
+		NotebookId: id,
+		OldName:    oldName,
+		NewName:    request.Name,
+	})
 	if err != nil {
-		return nil, err
-	}
-
-	for _, note := range notes {
-		msg := EmbedCreatedNoteMessage{
-			NoteId:             note.Id,
-			DeleteOldEmbedding: true,
-		}
-		msgJson, err := json.Marshal(msg)
-		if err != nil {
-			return nil, err
-		}
-		if ns.publisherService != nil {
-			err = ns.publisherService.Publish(
-				ctx,
-				msgJson,
-			)
-			if err != nil {
-				return nil, err
-			}
-		}
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
 	}
 
 	return &UpdateNotebookResponse{Id: id}, nil
 }
 
 func (ns *notebookService) UpdateParent(ctx context.Context, id uuid.UUID, request *UpdateNotebookParentRequest) (*UpdateNotebookParentResponse, error) {
+	if request.ParentId == id {
+		return nil, apperror.InvalidFields(apperror.FieldError{
+			Field:   "parent_id",
+			Message: "notebook can not be its own parent",
+		})
+	}
+
 	notebook, err := ns.notebookRepository.GetById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+
+	err = ensureNotebookExists(ctx, ns.notebookRepository, &request.ParentId, "parent_id")
+	if err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	updatedBy := "System"
+	oldParentId := notebook.ParentId
 	notebook.ParentId = &request.ParentId
 	notebook.UpdatedAt = &now
 	notebook.UpdatedBy = &updatedBy
@@ -109,6 +114,15 @@ func (ns *notebookService) UpdateParent(ctx context.Context, id uuid.UUID, reque
 		return nil, err
 	}
 
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNotebookMoved, evententity.NotebookMoved{
+		NotebookId:   id,
+		FromParentId: oldParentId,
+		ToParentId:   notebook.ParentId,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
+	}
+
 	return &UpdateNotebookParentResponse{Id: id}, nil
 }
 
@@ -149,7 +163,14 @@ func (ns *notebookService) Delete(ctx context.Context, id uuid.UUID) error {
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	err = publishEvent(ctx, ns.publisherService, evententity.TypeNotebookDeleted, evententity.NotebookDeleted{
+		NotebookId: id,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Publishing event failed", "error", err)
 	}
 
 	return nil
@@ -242,7 +263,7 @@ func NewNotebookService(
 	noteRepository noterepository.INoteRepository,
 	embeddingRepository embeddingrepository.IEmbeddingRepository,
 	publisherService publisherservice.IPublisherService,
-	db *pgxpool.Pool,
+	db database.DatabaseTxBeginner,
 ) INotebookService {
 	return &notebookService{
 		notebookRepository:  notebookRepository,
@@ -0,0 +1,166 @@
+package note_test
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	noteservice "ai-notetaking-be/internal/service/note"
+	"ai-notetaking-be/pkg/apperror"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNotebookServiceCreate(t *testing.T) {
+	f := newFixture(t)
+	parentId := f.createNotebook(t, "Projects", nil)
+
+	id := f.createNotebook(t, "Backend", &parentId)
+
+	notebook, err := f.notebookService.Show(f.ctx, id)
+	if err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	if notebook.Name != "Backend" || *notebook.ParentId != parentId || notebook.CreatedBy != "System" {
+		t.Fatalf("unexpected notebook %+v", notebook)
+	}
+	f.events(t)
+}
+
+func TestNotebookServiceCreateWithMissingParent(t *testing.T) {
+	f := newFixture(t)
+	parentId := uuid.New()
+
+	_, err := f.notebookService.Create(f.ctx, &noteservice.CreateNotebookRequest{Name: "Orphan", ParentId: &parentId})
+
+	assertKind(t, err, apperror.KindValidation)
+}
+
+func TestNotebookServiceUpdate(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNotebook(t, "Todo", nil)
+
+	_, err := f.notebookService.Update(f.ctx, id, &noteservice.UpdateNotebookRequest{Name: "Done"})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	notebook, _ := f.notebookService.Show(f.ctx, id)
+	if notebook.Name != "Done" || notebook.UpdatedAt == nil {
+		t.Fatalf("unexpected notebook %+v", notebook)
+	}
+	envelopes := f.events(t, evententity.TypeNotebookRenamed)
+	renamed := decodeData[evententity.NotebookRenamed](t, envelopes[0])
+	if renamed.NotebookId != id || renamed.OldName != "Todo" || renamed.NewName != "Done" {
+		t.Fatalf("unexpected event data %+v", renamed)
+	}
+}
+
+func TestNotebookServiceUpdateMissing(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.notebookService.Update(f.ctx, uuid.New(), &noteservice.UpdateNotebookRequest{Name: "Nothing"})
+
+	assertKind(t, err, apperror.KindNotFound)
+	f.events(t)
+}
+
+func TestNotebookServiceUpdateParent(t *testing.T) {
+	f := newFixture(t)
+	fromId := f.createNotebook(t, "Old", nil)
+	toId := f.createNotebook(t, "New", nil)
+	id := f.createNotebook(t, "Child", &fromId)
+
+	_, err := f.notebookService.UpdateParent(f.ctx, id, &noteservice.UpdateNotebookParentRequest{ParentId: toId})
+	if err != nil {
+		t.Fatalf("update parent: %v", err)
+	}
+
+	notebook, _ := f.notebookService.Show(f.ctx, id)
+	if *notebook.ParentId != toId {
+		t.Fatalf("parent is %s, want %s", notebook.ParentId, toId)
+	}
+	envelopes := f.events(t, evententity.TypeNotebookMoved)
+	moved := decodeData[evententity.NotebookMoved](t, envelopes[0])
+	if *moved.FromParentId != fromId || *moved.ToParentId != toId {
+		t.Fatalf("unexpected event data %+v", moved)
+	}
+}
+
+func TestNotebookServiceUpdateParentRejectsInvalidParents(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNotebook(t, "Notebook", nil)
+
+	_, err := f.notebookService.UpdateParent(f.ctx, id, &noteservice.UpdateNotebookParentRequest{ParentId: id})
+	assertKind(t, err, apperror.KindValidation)
+
+	_, err = f.notebookService.UpdateParent(f.ctx, id, &noteservice.UpdateNotebookParentRequest{ParentId: uuid.New()})
+	assertKind(t, err, apperror.KindValidation)
+
+	notebook, _ := f.notebookService.Show(f.ctx, id)
+	if notebook.ParentId != nil {
+		t.Fatal("parent was changed")
+	}
+	f.events(t)
+}
+
+func TestNotebookServiceDelete(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNotebook(t, "Trash", nil)
+	noteId := f.createNote(t, "Old", "", &id)
+	keptId := f.createNote(t, "Loose", "", nil)
+	f.embed(t, noteId, []float32{1})
+	f.embed(t, keptId, []float32{2})
+	f.publisher.Reset()
+
+	err := f.notebookService.Delete(f.ctx, id)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = f.notebookService.Show(f.ctx, id)
+	assertKind(t, err, apperror.KindNotFound)
+	_, err = f.noteService.Show(f.ctx, noteId)
+	assertKind(t, err, apperror.KindNotFound)
+	_, err = f.noteService.Show(f.ctx, keptId)
+	if err != nil {
+		t.Fatalf("note outside the notebook was deleted: %v", err)
+	}
+	ids, _ := f.embeddings.FindMostSimilarNoteIds(f.ctx, []float32{1})
+	if len(ids) != 1 || ids[0] != keptId {
+		t.Fatalf("embeddings of the deleted notes are still searched: %v", ids)
+	}
+	envelopes := f.events(t, evententity.TypeNotebookDeleted)
+	if decodeData[evententity.NotebookDeleted](t, envelopes[0]).NotebookId != id {
+		t.Fatal("event is not about the deleted notebook")
+	}
+}
+
+func TestNotebookServiceDeleteMissing(t *testing.T) {
+	f := newFixture(t)
+
+	err := f.notebookService.Delete(f.ctx, uuid.New())
+
+	assertKind(t, err, apperror.KindNotFound)
+	f.events(t)
+}
+
+func TestNotebookServiceGetAll(t *testing.T) {
+	f := newFixture(t)
+	id := f.createNotebook(t, "Recipes", nil)
+	inNotebookId := f.createNote(t, "Pancakes", "", &id)
+	looseId := f.createNote(t, "Groceries", "", nil)
+
+	res, err := f.notebookService.GetAll(f.ctx)
+	if err != nil {
+		t.Fatalf("get all: %v", err)
+	}
+
+	if len(res.Notebooks) != 1 || res.Notebooks[0].Id != id {
+		t.Fatalf("unexpected notebooks %+v", res.Notebooks)
+	}
+	if notes := res.Notebooks[0].Notes; len(notes) != 1 || notes[0].Id != inNotebookId {
+		t.Fatalf("unexpected notebook notes %+v", notes)
+	}
+	if len(res.Notes) != 1 || res.Notes[0].Id != looseId {
+		t.Fatalf("unexpected loose notes %+v", res.Notes)
+	}
+}
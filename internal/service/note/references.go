@@ -0,0 +1,27 @@
+package note
+
+import (
+	noterepository "ai-notetaking-be/internal/repository/note"
+	"ai-notetaking-be/pkg/apperror"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ensureNotebookExists reports a reference to a missing notebook as an invalid
+// request field, before Postgres gets to reject it as a foreign key violation.
+func ensureNotebookExists(ctx context.Context, notebookRepository noterepository.INotebookRepository, notebookId *uuid.UUID, field string) error {
+	if notebookId == nil {
+		return nil
+	}
+
+	_, err := notebookRepository.GetById(ctx, *notebookId)
+	if apperror.Is(err, apperror.KindNotFound) {
+		return apperror.InvalidFields(apperror.FieldError{
+			Field:   field,
+			Message: "notebook does not exist",
+		})
+	}
+
+	return err
+}
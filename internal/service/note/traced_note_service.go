@@ -0,0 +1,75 @@
+package note
+
+import (
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// tracedNoteService runs every service method in a span of its own.
+type tracedNoteService struct {
+	noteService INoteService
+}
+
+func (s *tracedNoteService) Create(ctx context.Context, request *CreateNoteRequest) (res *CreateNoteResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Create(ctx, request)
+}
+
+func (s *tracedNoteService) Search(ctx context.Context, request *SearchNoteRequest) (res []*SearchNoteResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Search")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Search(ctx, request)
+}
+
+func (s *tracedNoteService) Ask(ctx context.Context, request *AskNoteRequest) (res *AskNoteResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Ask")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Ask(ctx, request)
+}
+
+func (s *tracedNoteService) Related(ctx context.Context, id uuid.UUID, request *RelatedNoteRequest) (res []*RelatedNoteResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Related")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Related(ctx, id, request)
+}
+
+func (s *tracedNoteService) Update(ctx context.Context, id uuid.UUID, request *UpdateNoteRequest) (res *UpdateNoteResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Update(ctx, id, request)
+}
+
+func (s *tracedNoteService) UpdateNoteNotebook(ctx context.Context, id uuid.UUID, request *UpdateNoteNotebookRequest) (res *UpdateNoteNotebookResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.UpdateNoteNotebook")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.UpdateNoteNotebook(ctx, id, request)
+}
+
+func (s *tracedNoteService) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Delete(ctx, id)
+}
+
+func (s *tracedNoteService) Show(ctx context.Context, id uuid.UUID) (res *ShowNoteResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "noteService.Show")
+	defer func() { tracing.End(span, err) }()
+
+	return s.noteService.Show(ctx, id)
+}
+
+func NewTracedNoteService(noteService INoteService) INoteService {
+	return &tracedNoteService{
+		noteService: noteService,
+	}
+}
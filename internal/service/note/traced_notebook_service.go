@@ -0,0 +1,61 @@
+package note
+
+import (
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// tracedNotebookService runs every service method in a span of its own.
+type tracedNotebookService struct {
+	notebookService INotebookService
+}
+
+func (s *tracedNotebookService) Create(ctx context.Context, request *CreateNotebookRequest) (res *CreateNotebookResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notebookService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return s.notebookService.Create(ctx, request)
+}
+
+func (s *tracedNotebookService) Update(ctx context.Context, id uuid.UUID, request *UpdateNotebookRequest) (res *UpdateNotebookResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notebookService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	return s.notebookService.Update(ctx, id, request)
+}
+
+func (s *tracedNotebookService) UpdateParent(ctx context.Context, id uuid.UUID, request *UpdateNotebookParentRequest) (res *UpdateNotebookParentResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notebookService.UpdateParent")
+	defer func() { tracing.End(span, err) }()
+
+	return s.notebookService.UpdateParent(ctx, id, request)
+}
+
+func (s *tracedNotebookService) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notebookService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return s.notebookService.Delete(ctx, id)
+}
+
+func (s *tracedNotebookService) Show(ctx context.Context, id uuid.UUID) (res *ShowNotebookResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notebookService.Show")
+	defer func() { tracing.End(span, err) }()
+
+	return s.notebookService.Show(ctx, id)
+}
+
+func (s *tracedNotebookService) GetAll(ctx context.Context) (res *GetAllNotebookResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notebookService.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	return s.notebookService.GetAll(ctx)
+}
+
+func NewTracedNotebookService(notebookService INotebookService) INotebookService {
+	return &tracedNotebookService{
+		notebookService: notebookService,
+	}
+}
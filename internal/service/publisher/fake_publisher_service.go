@@ -0,0 +1,68 @@
+package publisher
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// FakePublisherService records the payloads it is given instead of sending
+// them, so tests can assert on the events a service published.
+type FakePublisherService struct {
+	mu       sync.Mutex
+	payloads [][]byte
+
+	// Err, when set, is returned by Publish and nothing is recorded.
+	Err error
+}
+
+func (mq *FakePublisherService) Publish(ctx context.Context, payload []byte) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	if mq.Err != nil {
+		return mq.Err
+	}
+	mq.payloads = append(mq.payloads, slices.Clone(payload))
+
+	return nil
+}
+
+func (mq *FakePublisherService) Close(ctx context.Context) error {
+	return nil
+}
+
+func (mq *FakePublisherService) Payloads() [][]byte {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	return slices.Clone(mq.payloads)
+}
+
+// Envelopes decodes the recorded payloads, in publish order.
+func (mq *FakePublisherService) Envelopes() ([]evententity.Envelope, error) {
+	envelopes := make([]evententity.Envelope, 0)
+	for _, payload := range mq.Payloads() {
+		var envelope evententity.Envelope
+		err := json.Unmarshal(payload, &envelope)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	return envelopes, nil
+}
+
+func (mq *FakePublisherService) Reset() {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	mq.payloads = nil
+}
+
+func NewFakePublisherService() *FakePublisherService {
+	return &FakePublisherService{}
+}
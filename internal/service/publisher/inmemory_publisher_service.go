@@ -1,6 +1,8 @@
 package publisher
 
 import (
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/tracing"
 	"context"
 
 	"github.com/ThreeDotsLabs/watermill"
@@ -14,7 +16,14 @@ type inMemoryPublisherService struct {
 }
 
 func (mq *inMemoryPublisherService) Publish(ctx context.Context, payload []byte) error {
-	err := mq.pubSub.Publish(mq.queueName, message.NewMessage(watermill.NewUUID(), payload))
+	msg := message.NewMessage(watermill.NewUUID(), payload)
+	requestId := logging.RequestId(ctx)
+	if requestId != "" {
+		msg.Metadata.Set(logging.RequestIdKey, requestId)
+	}
+	tracing.Inject(ctx, msg.Metadata)
+
+	err := mq.pubSub.Publish(mq.queueName, msg)
 	if err != nil {
 		return err
 	}
@@ -22,6 +31,11 @@ func (mq *inMemoryPublisherService) Publish(ctx context.Context, payload []byte)
 	return nil
 }
 
+// Close leaves the pub/sub open, the consumers share it.
+func (mq *inMemoryPublisherService) Close(ctx context.Context) error {
+	return nil
+}
+
 func NewInMemoryPublisherService(pubSub *gochannel.GoChannel, queueName string) IPublisherService {
 	return &inMemoryPublisherService{
 		pubSub:    pubSub,
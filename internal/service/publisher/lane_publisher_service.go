@@ -0,0 +1,36 @@
+package publisher
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// lanePublisherService routes each event to the queue of its priority lane, the
+// consumers of the bulk lane run with far less concurrency than interactive ones.
+type lanePublisherService struct {
+	interactive IPublisherService
+	bulk        IPublisherService
+}
+
+func (mq *lanePublisherService) Publish(ctx context.Context, payload []byte) error {
+	var envelope evententity.Envelope
+	err := json.Unmarshal(payload, &envelope)
+	if err == nil && envelope.IsBulk() {
+		return mq.bulk.Publish(ctx, payload)
+	}
+
+	return mq.interactive.Publish(ctx, payload)
+}
+
+func (mq *lanePublisherService) Close(ctx context.Context) error {
+	return errors.Join(mq.interactive.Close(ctx), mq.bulk.Close(ctx))
+}
+
+func NewLanePublisherService(interactive IPublisherService, bulk IPublisherService) IPublisherService {
+	return &lanePublisherService{
+		interactive: interactive,
+		bulk:        bulk,
+	}
+}
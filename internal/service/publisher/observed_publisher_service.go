@@ -0,0 +1,33 @@
+package publisher
+
+import (
+	"context"
+	"time"
+)
+
+type PublishObserveFunc func(err error, latency time.Duration)
+
+// observedPublisherService reports the outcome and latency of every publish.
+type observedPublisherService struct {
+	publisherService IPublisherService
+	observe          PublishObserveFunc
+}
+
+func (mq *observedPublisherService) Publish(ctx context.Context, payload []byte) error {
+	start := time.Now()
+	err := mq.publisherService.Publish(ctx, payload)
+	mq.observe(err, time.Since(start))
+
+	return err
+}
+
+func (mq *observedPublisherService) Close(ctx context.Context) error {
+	return mq.publisherService.Close(ctx)
+}
+
+func NewObservedPublisherService(publisherService IPublisherService, observe PublishObserveFunc) IPublisherService {
+	return &observedPublisherService{
+		publisherService: publisherService,
+		observe:          observe,
+	}
+}
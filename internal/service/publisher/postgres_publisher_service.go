@@ -0,0 +1,53 @@
+package publisher
+
+import (
+	jobentity "ai-notetaking-be/internal/entity/job"
+	jobrepository "ai-notetaking-be/internal/repository/job"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type postgresPublisherService struct {
+	queueName     string
+	jobRepository jobrepository.IJobRepository
+}
+
+func (mq *postgresPublisherService) Publish(ctx context.Context, payload []byte) error {
+	now := time.Now()
+	job := jobentity.Job{
+		Id:          uuid.New(),
+		QueueName:   mq.queueName,
+		Payload:     payload,
+		Status:      jobentity.StatusPending,
+		AvailableAt: now,
+		CreatedAt:   now,
+	}
+	err := mq.jobRepository.Create(ctx, &job)
+	if err != nil {
+		return err
+	}
+
+	// Wake-up only, consumers still poll so a lost notification just delays the
+	// job. Its error is not returned, the job is enqueued either way.
+	err = mq.jobRepository.Notify(ctx, mq.queueName, job.Id.String())
+	if err != nil {
+		slog.WarnContext(ctx, "Notifying job consumers failed", "queue", mq.queueName, "job_id", job.Id, "error", err)
+	}
+
+	return nil
+}
+
+// Close has nothing to release, jobs are written through the shared pool.
+func (mq *postgresPublisherService) Close(ctx context.Context) error {
+	return nil
+}
+
+func NewPostgresPublisherService(jobRepository jobrepository.IJobRepository, queueName string) IPublisherService {
+	return &postgresPublisherService{
+		queueName:     queueName,
+		jobRepository: jobRepository,
+	}
+}
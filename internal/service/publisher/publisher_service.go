@@ -1,65 +1,105 @@
 package publisher
 
 import (
+	"ai-notetaking-be/pkg/logging"
+	"ai-notetaking-be/pkg/rabbitmq"
+	"ai-notetaking-be/pkg/tracing"
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+var ErrPublishNotConfirmed = errors.New("rabbitmq: publish was not confirmed by the broker")
+
 type IPublisherService interface {
 	Publish(ctx context.Context, payload []byte) error
+	// Close releases the connection of the publisher, call it once nothing
+	// publishes anymore.
+	Close(ctx context.Context) error
 }
 
 type rabbitMqPublisherService struct {
-	ch *amqp.Channel
-	q  amqp.Queue
+	queueName string
+	conn      *rabbitmq.Connection
+
+	// How long a publish waits for a reconnect before it is rejected.
+	reconnectTimeout time.Duration
 }
 
 func (mq *rabbitMqPublisherService) Publish(ctx context.Context, payload []byte) error {
-	err := mq.ch.PublishWithContext(
+	waitCtx, cancel := context.WithTimeout(ctx, mq.reconnectTimeout)
+	defer cancel()
+	ch, err := mq.conn.WaitReady(waitCtx)
+	if err != nil {
+		return fmt.Errorf("publish to %s rejected: %w", mq.queueName, err)
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(
 		ctx,
 		"",
-		mq.q.Name,
+		mq.queueName,
 		true,
 		false,
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        payload,
+			MessageId:     uuid.NewString(),
+			CorrelationId: logging.RequestId(ctx),
+			Headers:       messageHeaders(ctx),
+			ContentType:   "application/json",
+			DeliveryMode:  amqp.Persistent,
+			Body:          payload,
 		},
 	)
 	if err != nil {
 		return err
 	}
 
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrPublishNotConfirmed
+	}
+
 	return nil
 }
 
-func NewRabbitMqPublisherService(connectionString string, queueName string) IPublisherService {
-	conn, err := amqp.Dial(connectionString)
-	if err != nil {
-		panic(fmt.Sprintf("RabbitMQ connection error, %s", err))
-	}
+func (mq *rabbitMqPublisherService) Close(ctx context.Context) error {
+	return mq.conn.Close()
+}
 
-	ch, err := conn.Channel()
-	if err != nil {
-		panic(fmt.Sprintf("RabbitMQ creating channel error, %s", err))
+// messageHeaders carries the request id and the trace context to the consumer.
+func messageHeaders(ctx context.Context) amqp.Table {
+	headers := make(amqp.Table)
+	requestId := logging.RequestId(ctx)
+	if requestId != "" {
+		headers[logging.RequestIdKey] = requestId
 	}
-
-	q, err := ch.QueueDeclare(
-		queueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		panic(fmt.Sprintf("RabbitMQ declaring queue error, %s", err))
+	for key, value := range tracing.Carrier(ctx) {
+		headers[key] = value
 	}
 
+	return headers
+}
+
+func NewRabbitMqPublisherService(connectionString string, queueName string) IPublisherService {
+	declareQueue := rabbitmq.DeclareQueue(queueName)
+	conn := rabbitmq.NewConnection(connectionString, func(ch *amqp.Channel) error {
+		err := declareQueue(ch)
+		if err != nil {
+			return err
+		}
+
+		return ch.Confirm(false)
+	})
+
 	return &rabbitMqPublisherService{
-		ch: ch,
-		q:  q,
+		queueName:        queueName,
+		conn:             conn,
+		reconnectTimeout: 5 * time.Second,
 	}
 }
@@ -0,0 +1,41 @@
+package publisher
+
+import (
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedPublisherService wraps every publish in a producer span. Transports
+// that carry metadata inject the span's context so the consumer continues the
+// trace.
+type tracedPublisherService struct {
+	publisherService IPublisherService
+	queueName        string
+}
+
+func (mq *tracedPublisherService) Publish(ctx context.Context, payload []byte) error {
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		mq.queueName+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", mq.queueName)),
+	)
+	err := mq.publisherService.Publish(ctx, payload)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (mq *tracedPublisherService) Close(ctx context.Context) error {
+	return mq.publisherService.Close(ctx)
+}
+
+func NewTracedPublisherService(publisherService IPublisherService, queueName string) IPublisherService {
+	return &tracedPublisherService{
+		publisherService: publisherService,
+		queueName:        queueName,
+	}
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// tracedWebhookService runs every service method in a span of its own.
+type tracedWebhookService struct {
+	webhookService IWebhookService
+}
+
+func (s *tracedWebhookService) Create(ctx context.Context, request *CreateWebhookSubscriptionRequest) (res *CreateWebhookSubscriptionResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhookService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return s.webhookService.Create(ctx, request)
+}
+
+func (s *tracedWebhookService) GetAll(ctx context.Context) (res []*ShowWebhookSubscriptionResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhookService.GetAll")
+	defer func() { tracing.End(span, err) }()
+
+	return s.webhookService.GetAll(ctx)
+}
+
+func (s *tracedWebhookService) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhookService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return s.webhookService.Delete(ctx, id)
+}
+
+func (s *tracedWebhookService) GetDeliveries(ctx context.Context, subscriptionId uuid.UUID) (res []*ShowWebhookDeliveryResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhookService.GetDeliveries")
+	defer func() { tracing.End(span, err) }()
+
+	return s.webhookService.GetDeliveries(ctx, subscriptionId)
+}
+
+func (s *tracedWebhookService) Redeliver(ctx context.Context, deliveryId uuid.UUID) (res *ShowWebhookDeliveryResponse, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhookService.Redeliver")
+	defer func() { tracing.End(span, err) }()
+
+	return s.webhookService.Redeliver(ctx, deliveryId)
+}
+
+func NewTracedWebhookService(webhookService IWebhookService) IWebhookService {
+	return &tracedWebhookService{
+		webhookService: webhookService,
+	}
+}
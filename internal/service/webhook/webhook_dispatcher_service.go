@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	evententity "ai-notetaking-be/internal/entity/event"
+	webhookentity "ai-notetaking-be/internal/entity/webhook"
+	webhookrepository "ai-notetaking-be/internal/repository/webhook"
+	"ai-notetaking-be/pkg/apperror"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// IWebhookDispatcherService records a delivery per matching subscription for every
+// event and sends them, retrying failed ones with exponential backoff.
+type IWebhookDispatcherService interface {
+	HandleEvent(ctx context.Context, envelope *evententity.Envelope) error
+	// Wake makes Run look for due deliveries now instead of at its next poll.
+	Wake()
+	// Run sends due deliveries until ctx is cancelled, finishing the ones it
+	// already claimed.
+	Run(ctx context.Context)
+	// Shutdown waits for Run to return until ctx expires, then aborts the
+	// deliveries still being sent.
+	Shutdown(ctx context.Context) error
+}
+
+type webhookDispatcherService struct {
+	subscriptionRepository webhookrepository.IWebhookSubscriptionRepository
+	deliveryRepository     webhookrepository.IWebhookDeliveryRepository
+
+	client       *http.Client
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+
+	wakeUp chan struct{}
+
+	workCtx    context.Context
+	cancelWork context.CancelFunc
+	done       chan struct{}
+}
+
+func (ws *webhookDispatcherService) HandleEvent(ctx context.Context, envelope *evententity.Envelope) error {
+	subscriptions, err := ws.subscriptionRepository.GetActiveByEventType(ctx, envelope.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		delivery := webhookentity.Delivery{
+			Id:             uuid.New(),
+			SubscriptionId: subscription.Id,
+			EventId:        envelope.Id,
+			EventType:      envelope.Type,
+			Payload:        payload,
+			Status:         webhookentity.DeliveryStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		err = ws.deliveryRepository.Create(ctx, &delivery)
+		if err != nil {
+			return err
+		}
+	}
+
+	ws.Wake()
+
+	return nil
+}
+
+func (ws *webhookDispatcherService) Wake() {
+	select {
+	case ws.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+func (ws *webhookDispatcherService) Run(ctx context.Context) {
+	defer close(ws.done)
+	ticker := time.NewTicker(ws.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-ws.wakeUp:
+		}
+
+		// A full batch means more are due, claim again without waiting.
+		for ws.dispatchDue(ctx) == ws.batchSize {
+		}
+	}
+}
+
+// dispatchDue sends one batch of due deliveries and returns how many it claimed.
+// They are sent one after the other, so the claim lasts as long as sending the
+// whole batch can take, otherwise another dispatcher would claim them again.
+func (ws *webhookDispatcherService) dispatchDue(ctx context.Context) int {
+	lease := time.Duration(ws.batchSize)*ws.client.Timeout + time.Minute
+	deliveries, err := ws.deliveryRepository.ClaimDue(ctx, ws.batchSize, lease)
+	if ctx.Err() != nil {
+		return 0
+	}
+	if err != nil {
+		slog.Error("Claiming webhook deliveries failed", "error", err)
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		err = ws.deliver(ws.workCtx, delivery)
+		if err != nil {
+			slog.Error("Recording webhook delivery failed", "delivery_id", delivery.Id, "error", err)
+		}
+	}
+
+	return len(deliveries)
+}
+
+func (ws *webhookDispatcherService) Shutdown(ctx context.Context) error {
+	select {
+	case <-ws.done:
+		return nil
+	case <-ctx.Done():
+		ws.cancelWork()
+		return errors.New("aborted in-flight webhook deliveries, they are sent again once their claim expires")
+	}
+}
+
+// deliver makes one attempt and stores its outcome. A failed attempt is not an
+// error, only failing to record it is.
+func (ws *webhookDispatcherService) deliver(ctx context.Context, delivery *webhookentity.Delivery) error {
+	subscription, err := ws.subscriptionRepository.GetById(ctx, delivery.SubscriptionId)
+	if err != nil && !apperror.Is(err, apperror.KindNotFound) {
+		return err
+	}
+
+	delivery.Attempts++
+	now := time.Now()
+	if subscription == nil {
+		reason := "subscription was deleted"
+		delivery.Status = webhookentity.DeliveryStatusFailed
+		delivery.LastError = &reason
+		return ws.deliveryRepository.UpdateResult(ctx, delivery)
+	}
+
+	responseStatus, responseBody, sendErr := ws.send(ctx, subscription, delivery)
+	delivery.ResponseStatus = responseStatus
+	delivery.ResponseBody = responseBody
+	delivery.LastError = nil
+	if sendErr == nil {
+		delivery.Status = webhookentity.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		return ws.deliveryRepository.UpdateResult(ctx, delivery)
+	}
+
+	reason := sendErr.Error()
+	delivery.LastError = &reason
+	if delivery.Attempts >= ws.maxAttempts {
+		delivery.Status = webhookentity.DeliveryStatusFailed
+	} else {
+		delivery.Status = webhookentity.DeliveryStatusPending
+		delivery.NextAttemptAt = now.Add(ws.backoff(delivery.Attempts))
+	}
+
+	return ws.deliveryRepository.UpdateResult(ctx, delivery)
+}
+
+func (ws *webhookDispatcherService) send(ctx context.Context, subscription *webhookentity.Subscription, delivery *webhookentity.Delivery) (*int, *string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.Id.String())
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	res, err := ws.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	responseBody := string(body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &res.StatusCode, &responseBody, fmt.Errorf("webhook endpoint returned status %d", res.StatusCode)
+	}
+
+	return &res.StatusCode, &responseBody, nil
+}
+
+func (ws *webhookDispatcherService) backoff(attempts int) time.Duration {
+	wait := ws.baseBackoff << (attempts - 1)
+	if wait <= 0 || wait > ws.maxBackoff {
+		return ws.maxBackoff
+	}
+
+	return wait
+}
+
+// Sign computes the signature receivers should compare against the
+// X-Webhook-Signature header: hex HMAC-SHA256 of "<timestamp>.<body>".
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewWebhookDispatcherService(
+	subscriptionRepository webhookrepository.IWebhookSubscriptionRepository,
+	deliveryRepository webhookrepository.IWebhookDeliveryRepository,
+) IWebhookDispatcherService {
+	workCtx, cancelWork := context.WithCancel(context.Background())
+
+	return &webhookDispatcherService{
+		subscriptionRepository: subscriptionRepository,
+		deliveryRepository:     deliveryRepository,
+		client:                 &http.Client{Timeout: 10 * time.Second},
+		pollInterval:           10 * time.Second,
+		batchSize:              10,
+		maxAttempts:            8,
+		baseBackoff:            30 * time.Second,
+		maxBackoff:             time.Hour,
+		wakeUp:                 make(chan struct{}, 1),
+		workCtx:                workCtx,
+		cancelWork:             cancelWork,
+		done:                   make(chan struct{}),
+	}
+}
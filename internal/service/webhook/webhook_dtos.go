@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateWebhookSubscriptionRequest struct {
+	Url        string   `json:"url" validate:"required,http_url,max=2048"`
+	Secret     string   `json:"secret" validate:"max=255"`
+	EventTypes []string `json:"event_types" validate:"dive,oneof=note.created note.updated note.moved note.deleted notebook.renamed notebook.moved notebook.deleted"`
+}
+
+type CreateWebhookSubscriptionResponse struct {
+	Id     uuid.UUID `json:"id"`
+	Secret string    `json:"secret"`
+}
+
+type ShowWebhookSubscriptionResponse struct {
+	Id         uuid.UUID  `json:"id"`
+	Url        string     `json:"url"`
+	EventTypes []string   `json:"event_types"`
+	IsActive   bool       `json:"is_active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  string     `json:"created_by"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+	UpdatedBy  *string    `json:"updated_by"`
+}
+
+type ShowWebhookDeliveryResponse struct {
+	Id             uuid.UUID  `json:"id"`
+	SubscriptionId uuid.UUID  `json:"subscription_id"`
+	EventId        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status"`
+	ResponseBody   *string    `json:"response_body"`
+	LastError      *string    `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
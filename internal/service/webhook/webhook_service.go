@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	webhookentity "ai-notetaking-be/internal/entity/webhook"
+	webhookrepository "ai-notetaking-be/internal/repository/webhook"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IWebhookService interface {
+	Create(ctx context.Context, request *CreateWebhookSubscriptionRequest) (*CreateWebhookSubscriptionResponse, error)
+	GetAll(ctx context.Context) ([]*ShowWebhookSubscriptionResponse, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetDeliveries(ctx context.Context, subscriptionId uuid.UUID) ([]*ShowWebhookDeliveryResponse, error)
+	Redeliver(ctx context.Context, deliveryId uuid.UUID) (*ShowWebhookDeliveryResponse, error)
+}
+
+type webhookService struct {
+	subscriptionRepository webhookrepository.IWebhookSubscriptionRepository
+	deliveryRepository     webhookrepository.IWebhookDeliveryRepository
+	dispatcherService      IWebhookDispatcherService
+}
+
+func (ws *webhookService) Create(ctx context.Context, request *CreateWebhookSubscriptionRequest) (*CreateWebhookSubscriptionResponse, error) {
+	secret := request.Secret
+	if secret == "" {
+		randomBytes := make([]byte, 32)
+		_, err := rand.Read(randomBytes)
+		if err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(randomBytes)
+	}
+
+	eventTypes := request.EventTypes
+	if eventTypes == nil {
+		eventTypes = make([]string, 0)
+	}
+
+	id := uuid.New()
+	subscription := webhookentity.Subscription{
+		Id:         id,
+		Url:        request.Url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		IsActive:   true,
+		CreatedAt:  time.Now(),
+		CreatedBy:  "System",
+	}
+	err := ws.subscriptionRepository.Create(ctx, &subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateWebhookSubscriptionResponse{Id: id, Secret: secret}, nil
+}
+
+func (ws *webhookService) GetAll(ctx context.Context) ([]*ShowWebhookSubscriptionResponse, error) {
+	subscriptions, err := ws.subscriptionRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*ShowWebhookSubscriptionResponse, 0)
+	for _, subscription := range subscriptions {
+		res = append(res, &ShowWebhookSubscriptionResponse{
+			Id:         subscription.Id,
+			Url:        subscription.Url,
+			EventTypes: subscription.EventTypes,
+			IsActive:   subscription.IsActive,
+			CreatedAt:  subscription.CreatedAt,
+			CreatedBy:  subscription.CreatedBy,
+			UpdatedAt:  subscription.UpdatedAt,
+			UpdatedBy:  subscription.UpdatedBy,
+		})
+	}
+
+	return res, nil
+}
+
+func (ws *webhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	return ws.subscriptionRepository.Delete(ctx, id, "System")
+}
+
+func (ws *webhookService) GetDeliveries(ctx context.Context, subscriptionId uuid.UUID) ([]*ShowWebhookDeliveryResponse, error) {
+	deliveries, err := ws.deliveryRepository.GetBySubscriptionId(ctx, subscriptionId, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*ShowWebhookDeliveryResponse, 0)
+	for _, delivery := range deliveries {
+		res = append(res, toDeliveryResponse(delivery))
+	}
+
+	return res, nil
+}
+
+// Redeliver schedules the delivery to be sent again right away. Sending is left
+// to the dispatcher, which claims it first, so it is never sent twice at once.
+func (ws *webhookService) Redeliver(ctx context.Context, deliveryId uuid.UUID) (*ShowWebhookDeliveryResponse, error) {
+	delivery, err := ws.deliveryRepository.Requeue(ctx, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	ws.dispatcherService.Wake()
+
+	return toDeliveryResponse(delivery), nil
+}
+
+func toDeliveryResponse(delivery *webhookentity.Delivery) *ShowWebhookDeliveryResponse {
+	return &ShowWebhookDeliveryResponse{
+		Id:             delivery.Id,
+		SubscriptionId: delivery.SubscriptionId,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func NewWebhookService(
+	subscriptionRepository webhookrepository.IWebhookSubscriptionRepository,
+	deliveryRepository webhookrepository.IWebhookDeliveryRepository,
+	dispatcherService IWebhookDispatcherService,
+) IWebhookService {
+	return &webhookService{
+		subscriptionRepository: subscriptionRepository,
+		deliveryRepository:     deliveryRepository,
+		dispatcherService:      dispatcherService,
+	}
+}
@@ -0,0 +1,42 @@
+package wiring
+
+import (
+	"ai-notetaking-be/internal/config"
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	"ai-notetaking-be/migrations"
+	"ai-notetaking-be/pkg/database"
+	"ai-notetaking-be/pkg/migration"
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConnectDatabase connects and applies pending migrations when configured to.
+func ConnectDatabase(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	db := database.ConnectDB(cfg.ConnectionString)
+	if cfg.MigrateOnStartup {
+		migrator, err := migration.New(db, migrations.FS)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		_, err = migrator.Up(ctx)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+func NewEmbeddingRepository(db *pgxpool.Pool, cfg config.VectorSearchConfig) embeddingrepository.IEmbeddingRepository {
+	return embeddingrepository.NewEmbeddingRepository(
+		db,
+		embeddingrepository.DistanceMetric(cfg.Metric),
+		embeddingrepository.SearchOptions{
+			EfSearch: cfg.EfSearch,
+			Probes:   cfg.Probes,
+		},
+	)
+}
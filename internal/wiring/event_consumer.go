@@ -0,0 +1,114 @@
+// Package wiring builds the parts cmd/rest and cmd/embednoteconsumer share, so
+// both entrypoints handle note events the same way.
+package wiring
+
+import (
+	"ai-notetaking-be/internal/config"
+	"ai-notetaking-be/internal/metrics"
+	embeddingrepository "ai-notetaking-be/internal/repository/embedding"
+	messagerepository "ai-notetaking-be/internal/repository/message"
+	noterepository "ai-notetaking-be/internal/repository/note"
+	webhookrepository "ai-notetaking-be/internal/repository/webhook"
+	"ai-notetaking-be/internal/service/consumer"
+	webhookservice "ai-notetaking-be/internal/service/webhook"
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/lifecycle"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventConsumer handles note events whatever transport delivers them: it
+// embeds notes and sends webhooks. Consumers hand their messages to Handler.
+type EventConsumer struct {
+	Limiter     *consumer.ConcurrencyLimiter
+	BulkLimiter *consumer.ConcurrencyLimiter
+	// GeminiProvider is the bare provider for the readiness probe, notes are
+	// embedded with EmbeddingProvider.
+	GeminiProvider    embedding.IEmbeddingProvider
+	EmbeddingProvider embedding.IEmbeddingProvider
+	Pipeline          consumer.IEmbedNotePipeline
+	WebhookDispatcher webhookservice.IWebhookDispatcherService
+	Handler           consumer.MessageHandler
+}
+
+func NewEventConsumer(
+	cfg *config.Config,
+	db *pgxpool.Pool,
+	appMetrics *metrics.Metrics,
+	noteRepository noterepository.INoteRepository,
+	embeddingRepository embeddingrepository.IEmbeddingRepository,
+) *EventConsumer {
+	webhookDispatcher := webhookservice.NewWebhookDispatcherService(
+		webhookrepository.NewWebhookSubscriptionRepository(db),
+		webhookrepository.NewWebhookDeliveryRepository(db),
+	)
+
+	limiter := consumer.NewConcurrencyLimiter(consumer.ConcurrencyOptions{
+		MaxConcurrent: cfg.Consumer.MaxConcurrent,
+		MinConcurrent: cfg.Consumer.MinConcurrent,
+		Adaptive:      cfg.Consumer.Adaptive,
+		LatencyTarget: cfg.Consumer.LatencyTarget,
+	})
+	// Bulk work only ever gets a few slots so interactive notes are never stuck behind it.
+	bulkLimiter := consumer.NewConcurrencyLimiter(consumer.ConcurrencyOptions{
+		MaxConcurrent: cfg.Consumer.BulkMaxConcurrent,
+	})
+	appMetrics.ObserveLimiter("interactive", limiter)
+	appMetrics.ObserveLimiter("bulk", bulkLimiter)
+
+	geminiProvider := embedding.NewGeminiProvider(cfg.Gemini.BaseUrl, cfg.Gemini.ApiKey)
+	embeddingProvider := embedding.NewRateLimitedProvider(
+		embedding.NewObservedProvider(
+			embedding.NewObservedProvider(
+				embedding.NewTracedProvider(geminiProvider),
+				appMetrics.ObserveEmbedding("gemini"),
+			),
+			limiter.ObserveEmbedding,
+		),
+		cfg.Gemini.RateLimit,
+		cfg.Gemini.RateBurst,
+	)
+	pipeline := consumer.NewEmbedNotePipeline(
+		db,
+		embeddingRepository,
+		noteRepository,
+		embeddingProvider,
+		consumer.EmbedNotePipelineOptions{
+			BatchSize:        cfg.Embedding.BatchSize,
+			BatchWait:        cfg.Embedding.BatchWait,
+			DebounceWindow:   cfg.Embedding.DebounceWindow,
+			DebounceMaxDelay: cfg.Embedding.DebounceMaxDelay,
+		},
+	)
+
+	router := consumer.NewEventRouter()
+	router.Subscribe("embedding", pipeline.HandleEvent, consumer.EmbedNoteEventTypes...)
+	router.Subscribe("event-log", consumer.LogEvent, consumer.AllEvents)
+	router.Subscribe("webhook", webhookDispatcher.HandleEvent, consumer.AllEvents)
+	handler := consumer.ChainMiddleware(
+		router.Handle,
+		consumer.TracingMiddleware(),
+		consumer.LoggingMiddleware(),
+		consumer.MetricsMiddleware(appMetrics),
+		consumer.IdempotencyMiddleware(messagerepository.NewProcessedMessageRepository(db)),
+		consumer.RetryMiddleware(3, time.Second),
+	)
+
+	return &EventConsumer{
+		Limiter:           limiter,
+		BulkLimiter:       bulkLimiter,
+		GeminiProvider:    geminiProvider,
+		EmbeddingProvider: embeddingProvider,
+		Pipeline:          pipeline,
+		WebhookDispatcher: webhookDispatcher,
+		Handler:           handler,
+	}
+}
+
+// OnShutdown registers the pipeline and the webhook dispatcher, after the
+// consumers feeding them and before the database they write to.
+func (e *EventConsumer) OnShutdown(lc *lifecycle.Manager) {
+	lc.OnShutdown("embedding pipeline", e.Pipeline.Shutdown)
+	lc.OnShutdown("webhook dispatcher", e.WebhookDispatcher.Shutdown)
+}
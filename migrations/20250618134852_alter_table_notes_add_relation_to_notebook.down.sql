@@ -1 +1 @@
-ALTER TBALE notes DROP COLUMN notebook_id;
\ No newline at end of file
+ALTER TABLE notes DROP COLUMN notebook_id;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE "jobs" (
+    id UUID PRIMARY KEY,
+    queue_name TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT DEFAULT NULL,
+    available_at TIMESTAMPTZ NOT NULL,
+    locked_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_jobs_queue_name_status_available_at ON jobs (queue_name, status, available_at);
@@ -0,0 +1,36 @@
+CREATE TABLE "webhook_subscriptions" (
+    id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOL NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NULL,
+    updated_by TEXT DEFAULT NULL,
+    is_deleted BOOL DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ DEFAULT NULL,
+    deleted_by TEXT DEFAULT NULL
+);
+
+CREATE TABLE "webhook_deliveries" (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT DEFAULT NULL,
+    response_body TEXT DEFAULT NULL,
+    last_error TEXT DEFAULT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+ALTER TABLE "webhook_deliveries"
+ADD CONSTRAINT "fk_webhook_deliveries_webhook_subscriptions" FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id);
+ALTER TABLE "webhook_deliveries"
+ADD CONSTRAINT "uq_webhook_deliveries_subscription_id_event_id" UNIQUE (subscription_id, event_id);
+CREATE INDEX idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, created_at);
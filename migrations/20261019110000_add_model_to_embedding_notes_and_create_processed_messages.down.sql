@@ -0,0 +1,3 @@
+DROP TABLE processed_messages;
+DROP INDEX uq_embedding_notes_note_id_model_live;
+ALTER TABLE embedding_notes DROP COLUMN model;
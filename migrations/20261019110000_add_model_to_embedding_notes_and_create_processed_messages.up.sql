@@ -0,0 +1,20 @@
+ALTER TABLE "embedding_notes"
+ADD COLUMN model TEXT NOT NULL DEFAULT 'gemini-embedding-exp-03-07';
+ALTER TABLE "embedding_notes"
+ALTER COLUMN model DROP DEFAULT;
+
+UPDATE embedding_notes
+SET is_deleted = true, deleted_at = NOW(), deleted_by = 'System'
+WHERE is_deleted = false
+    AND id NOT IN (
+        SELECT DISTINCT ON (note_id, model) id
+        FROM embedding_notes
+        WHERE is_deleted = false
+        ORDER BY note_id, model, created_at DESC
+    );
+CREATE UNIQUE INDEX uq_embedding_notes_note_id_model_live ON embedding_notes (note_id, model) WHERE is_deleted = false;
+
+CREATE TABLE "processed_messages" (
+    id TEXT PRIMARY KEY,
+    processed_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,6 @@
+-- migrate:no-transaction
+-- For the default cosine metric, `rest vector index` builds the others. Built
+-- concurrently so writes to embedding_notes go on during the build. A failed
+-- build leaves an invalid index and a dirty version, drop the index, reset
+-- the dirty flag and migrate again.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_embedding_notes_embedding_hnsw_cosine ON embedding_notes USING hnsw (embedding vector_cosine_ops) WHERE is_deleted = false;
@@ -0,0 +1,8 @@
+-- No dimensions on the column, every provider and model has its own.
+CREATE TABLE "query_embedding_cache" (
+    key TEXT PRIMARY KEY,
+    embedding vector NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_query_embedding_cache_expires_at ON query_embedding_cache (expires_at);
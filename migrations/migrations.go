@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations so every binary can apply them,
+// see pkg/migration.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
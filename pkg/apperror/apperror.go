@@ -0,0 +1,112 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindValidation
+	KindConflict
+	KindForbidden
+	KindUnavailable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindValidation:
+		return "validation_failed"
+	case KindConflict:
+		return "conflict"
+	case KindForbidden:
+		return "forbidden"
+	case KindUnavailable:
+		return "upstream_unavailable"
+	}
+
+	return "internal"
+}
+
+// Error is a domain error. Message and Fields are safe to show to API clients,
+// Err is the underlying cause and is only logged.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind Kind, message string, err error) error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func NotFound(message string) error {
+	return New(KindNotFound, message, nil)
+}
+
+func Validation(message string) error {
+	return New(KindValidation, message, nil)
+}
+
+// InvalidFields is a validation error pointing at the offending request fields.
+func InvalidFields(fields ...FieldError) error {
+	return &Error{
+		Kind:    KindValidation,
+		Message: "request validation failed",
+		Fields:  fields,
+	}
+}
+
+func Conflict(message string) error {
+	return New(KindConflict, message, nil)
+}
+
+func Forbidden(message string) error {
+	return New(KindForbidden, message, nil)
+}
+
+// Unavailable reports a dependency such as the embedding server failing us.
+func Unavailable(message string, err error) error {
+	return New(KindUnavailable, message, err)
+}
+
+// KindOf returns KindInternal for errors that are not domain errors.
+func KindOf(err error) Kind {
+	var target *Error
+	if errors.As(err, &target) {
+		return target.Kind
+	}
+
+	return KindInternal
+}
+
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
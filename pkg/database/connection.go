@@ -2,24 +2,37 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func ConnectDB(connectionString string) *pgxpool.Pool {
-	var err error
-	db, err := pgxpool.New(context.Background(), connectionString)
+	config, err := pgxpool.ParseConfig(connectionString)
 	if err != nil {
-		log.Fatalf("Unable to connect to DB: %v", err)
+		log.Fatalf("Invalid DB connection string: %v", err)
 	}
+	config.ConnConfig.Tracer = queryTracer{}
 
-	_, err = db.Exec(context.Background(), `
-		CREATE EXTENSION IF NOT EXISTS vector;
-	`)
+	db, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
-		log.Fatalf("Failed to create vector extension: %v", err)
+		log.Fatalf("Unable to connect to DB: %v", err)
 	}
 
 	return db
 }
+
+// CheckVectorExtension fails unless pgvector is installed in the database.
+func CheckVectorExtension(ctx context.Context, db DatabaseQueryer) error {
+	var installed bool
+	err := db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector')").Scan(&installed)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return errors.New("pgvector extension is not installed")
+	}
+
+	return nil
+}
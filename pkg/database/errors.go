@@ -0,0 +1,32 @@
+package database
+
+import (
+	"ai-notetaking-be/pkg/apperror"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// TranslateError turns constraint violations of writes to entity into domain
+// errors, anything else is returned as is.
+func TranslateError(err error, entity string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case foreignKeyViolation:
+		return apperror.New(apperror.KindValidation, fmt.Sprintf("%s references a record that does not exist", entity), err)
+	case uniqueViolation:
+		return apperror.New(apperror.KindConflict, fmt.Sprintf("%s already exists", entity), err)
+	}
+
+	return err
+}
@@ -11,4 +11,11 @@ type DatabaseQueryer interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	// Begin starts a transaction, or a savepoint when already inside one.
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// DatabaseTxBeginner starts the transactions services hand to UsingTx.
+type DatabaseTxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 }
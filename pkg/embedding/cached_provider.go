@@ -0,0 +1,186 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CacheTierMemory = "memory"
+	CacheTierStore  = "store"
+
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// ICacheStore is the tier behind the in process cache, shared by every
+// instance, e.g. a database table.
+type ICacheStore interface {
+	// Get reports false for missing and expired keys.
+	Get(ctx context.Context, key string) ([]float32, bool, error)
+	Set(ctx context.Context, key string, embedding []float32, expiresAt time.Time) error
+}
+
+type CacheObserveFunc func(tier string, result string)
+
+type CacheOptions struct {
+	// Size is the number of embeddings kept in process, 0 disables the tier.
+	Size int
+	TTL  time.Duration
+	// Store is optional, StoreTTL is how long it keeps an embedding.
+	Store    ICacheStore
+	StoreTTL time.Duration
+	Observe  CacheObserveFunc
+}
+
+type cacheEntry struct {
+	key       string
+	embedding []float32
+	expiresAt time.Time
+}
+
+// cachedProvider answers repeated Embed calls for the same text from an LRU
+// and then from the store before asking the provider. EmbedBatch embeds
+// documents, which rarely repeat, so it always goes to the provider.
+type cachedProvider struct {
+	IEmbeddingProvider
+	options CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func (p *cachedProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	key := CacheKey(p.Name(), p.Model(), taskType, text)
+
+	embedding, ok := p.getMemory(key)
+	if ok {
+		return embedding, nil
+	}
+
+	if p.options.Store != nil {
+		embedding, ok, err := p.options.Store.Get(ctx, key)
+		switch {
+		case err != nil:
+			// A broken store only costs the provider call.
+			slog.WarnContext(ctx, "Reading the embedding cache failed", "error", err)
+			p.observe(CacheTierStore, CacheError)
+		case ok:
+			p.observe(CacheTierStore, CacheHit)
+			p.setMemory(key, embedding)
+			return slices.Clone(embedding), nil
+		default:
+			p.observe(CacheTierStore, CacheMiss)
+		}
+	}
+
+	// The normalized text is what gets cached, so embed exactly that.
+	embedding, err := p.IEmbeddingProvider.Embed(ctx, NormalizeQuery(text), taskType)
+	if err != nil {
+		return nil, err
+	}
+
+	p.setMemory(key, embedding)
+	if p.options.Store != nil {
+		err = p.options.Store.Set(ctx, key, embedding, time.Now().Add(p.options.StoreTTL))
+		if err != nil {
+			slog.WarnContext(ctx, "Writing the embedding cache failed", "error", err)
+		}
+	}
+
+	return embedding, nil
+}
+
+func (p *cachedProvider) getMemory(key string) ([]float32, bool) {
+	if p.options.Size <= 0 {
+		return nil, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	element, ok := p.entries[key]
+	if ok && time.Now().After(element.Value.(*cacheEntry).expiresAt) {
+		p.order.Remove(element)
+		delete(p.entries, key)
+		ok = false
+	}
+	if !ok {
+		p.observe(CacheTierMemory, CacheMiss)
+		return nil, false
+	}
+
+	p.order.MoveToFront(element)
+	p.observe(CacheTierMemory, CacheHit)
+
+	return slices.Clone(element.Value.(*cacheEntry).embedding), true
+}
+
+func (p *cachedProvider) setMemory(key string, embedding []float32) {
+	if p.options.Size <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := &cacheEntry{
+		key:       key,
+		embedding: slices.Clone(embedding),
+		expiresAt: time.Now().Add(p.options.TTL),
+	}
+	if element, ok := p.entries[key]; ok {
+		element.Value = entry
+		p.order.MoveToFront(element)
+		return
+	}
+
+	p.entries[key] = p.order.PushFront(entry)
+	for p.order.Len() > p.options.Size {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (p *cachedProvider) observe(tier string, result string) {
+	if p.options.Observe != nil {
+		p.options.Observe(tier, result)
+	}
+}
+
+// NormalizeQuery trims text and collapses its whitespace, which does not
+// change what a query means. Case is kept, embedding models tell it apart.
+func NormalizeQuery(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// CacheKey hashes the provider, model, task type and normalized text, the
+// same text embeds differently under any other of them.
+func CacheKey(provider string, model string, taskType string, text string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{provider, model, taskType, NormalizeQuery(text)}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewCachedProvider returns provider itself when options enable neither tier.
+func NewCachedProvider(provider IEmbeddingProvider, options CacheOptions) IEmbeddingProvider {
+	if options.Size <= 0 && options.Store == nil {
+		return provider
+	}
+
+	return &cachedProvider{
+		IEmbeddingProvider: provider,
+		options:            options,
+		entries:            make(map[string]*list.Element),
+		order:              list.New(),
+	}
+}
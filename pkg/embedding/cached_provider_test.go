@@ -0,0 +1,147 @@
+package embedding_test
+
+import (
+	"ai-notetaking-be/pkg/embedding"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// countingProvider embeds a text to its length and counts the calls.
+type countingProvider struct {
+	calls []string
+}
+
+func (p *countingProvider) Name() string      { return "counting" }
+func (p *countingProvider) Model() string     { return "model" }
+func (p *countingProvider) MaxBatchSize() int { return 10 }
+
+func (p *countingProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	p.calls = append(p.calls, text)
+	return []float32{float32(len(text))}, nil
+}
+
+func (p *countingProvider) EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	values := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		value, _ := p.Embed(ctx, text, taskType)
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+type mapStore struct {
+	entries map[string][]float32
+	err     error
+}
+
+func (s *mapStore) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	value, ok := s.entries[key]
+	return value, ok, s.err
+}
+
+func (s *mapStore) Set(ctx context.Context, key string, value []float32, expiresAt time.Time) error {
+	s.entries[key] = value
+	return s.err
+}
+
+type observations map[string]int
+
+func (o observations) observe(tier string, result string) {
+	o[tier+" "+result]++
+}
+
+func TestCachedProviderNormalizesQueries(t *testing.T) {
+	provider := &countingProvider{}
+	seen := observations{}
+	cached := embedding.NewCachedProvider(provider, embedding.CacheOptions{Size: 10, TTL: time.Hour, Observe: seen.observe})
+	ctx := context.Background()
+
+	for _, text := range []string{"wifi password", " wifi\tpassword\n", "Wifi password"} {
+		_, err := cached.Embed(ctx, text, embedding.TaskTypeRetrievalQuery)
+		if err != nil {
+			t.Fatalf("embed: %v", err)
+		}
+	}
+	_, _ = cached.Embed(ctx, "wifi password", embedding.TaskTypeRetrievalDocument)
+
+	if !slices.Equal(provider.calls, []string{"wifi password", "Wifi password", "wifi password"}) {
+		t.Fatalf("provider embedded %q", provider.calls)
+	}
+	if seen["memory hit"] != 1 || seen["memory miss"] != 3 {
+		t.Fatalf("observed %v", seen)
+	}
+}
+
+func TestCachedProviderEvictsLeastRecentlyUsed(t *testing.T) {
+	provider := &countingProvider{}
+	cached := embedding.NewCachedProvider(provider, embedding.CacheOptions{Size: 2, TTL: time.Hour})
+	ctx := context.Background()
+
+	for _, text := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, _ = cached.Embed(ctx, text, embedding.TaskTypeRetrievalQuery)
+	}
+
+	// c evicts b, the least recently used, so only b is embedded again.
+	if !slices.Equal(provider.calls, []string{"a", "b", "c", "b"}) {
+		t.Fatalf("provider embedded %q", provider.calls)
+	}
+}
+
+func TestCachedProviderExpiresEntries(t *testing.T) {
+	provider := &countingProvider{}
+	cached := embedding.NewCachedProvider(provider, embedding.CacheOptions{Size: 10, TTL: time.Millisecond})
+	ctx := context.Background()
+
+	_, _ = cached.Embed(ctx, "a", embedding.TaskTypeRetrievalQuery)
+	time.Sleep(5 * time.Millisecond)
+	_, _ = cached.Embed(ctx, "a", embedding.TaskTypeRetrievalQuery)
+
+	if len(provider.calls) != 2 {
+		t.Fatalf("provider was called %d times, want the expired entry embedded again", len(provider.calls))
+	}
+}
+
+func TestCachedProviderSharesThroughStore(t *testing.T) {
+	store := &mapStore{entries: make(map[string][]float32)}
+	ctx := context.Background()
+
+	first := &countingProvider{}
+	_, _ = embedding.NewCachedProvider(first, embedding.CacheOptions{Size: 10, TTL: time.Hour, Store: store, StoreTTL: time.Hour}).
+		Embed(ctx, "shared", embedding.TaskTypeRetrievalQuery)
+
+	second := &countingProvider{}
+	seen := observations{}
+	cached := embedding.NewCachedProvider(second, embedding.CacheOptions{Size: 10, TTL: time.Hour, Store: store, StoreTTL: time.Hour, Observe: seen.observe})
+	for range 2 {
+		value, err := cached.Embed(ctx, "shared", embedding.TaskTypeRetrievalQuery)
+		if err != nil || !slices.Equal(value, []float32{6}) {
+			t.Fatalf("embed = %v, %v", value, err)
+		}
+	}
+
+	if len(second.calls) != 0 {
+		t.Fatalf("second instance embedded %q instead of reading the store", second.calls)
+	}
+	if seen["store hit"] != 1 || seen["memory hit"] != 1 {
+		t.Fatalf("observed %v, want the store hit to fill the memory tier", seen)
+	}
+}
+
+func TestCachedProviderFallsBackWhenStoreFails(t *testing.T) {
+	store := &mapStore{entries: make(map[string][]float32), err: errors.New("connection refused")}
+	provider := &countingProvider{}
+	seen := observations{}
+	cached := embedding.NewCachedProvider(provider, embedding.CacheOptions{Store: store, StoreTTL: time.Hour, Observe: seen.observe})
+
+	value, err := cached.Embed(context.Background(), "text", embedding.TaskTypeRetrievalQuery)
+	if err != nil || !slices.Equal(value, []float32{4}) {
+		t.Fatalf("embed = %v, %v, want the provider result", value, err)
+	}
+	if seen["store error"] != 1 {
+		t.Fatalf("observed %v", seen)
+	}
+}
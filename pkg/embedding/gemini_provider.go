@@ -0,0 +1,64 @@
+package embedding
+
+import (
+	"ai-notetaking-be/pkg/gemini"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type geminiProvider struct {
+	baseUrl string
+	apiKey  string
+}
+
+func (p *geminiProvider) Name() string {
+	return "gemini"
+}
+
+func (p *geminiProvider) Model() string {
+	return gemini.EmbeddingModel
+}
+
+func (p *geminiProvider) MaxBatchSize() int {
+	return gemini.MaxBatchSize
+}
+
+func (p *geminiProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	res, err := gemini.GetEmbedding(ctx, p.baseUrl, p.apiKey, text, taskType)
+	if err != nil {
+		return nil, wrapGeminiError(err)
+	}
+
+	return res.Embedding.Values, nil
+}
+
+func (p *geminiProvider) EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	res, err := gemini.GetBatchEmbedding(ctx, p.baseUrl, p.apiKey, texts, taskType)
+	if err != nil {
+		return nil, wrapGeminiError(err)
+	}
+
+	values := make([][]float32, 0, len(res.Embeddings))
+	for _, e := range res.Embeddings {
+		values = append(values, e.Values)
+	}
+
+	return values, nil
+}
+
+func wrapGeminiError(err error) error {
+	var embedErr *gemini.EmbedError
+	if errors.As(err, &embedErr) && embedErr.Type == gemini.ErrTypeRateLimited {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+
+	return err
+}
+
+func NewGeminiProvider(baseUrl string, apiKey string) IEmbeddingProvider {
+	return &geminiProvider{
+		baseUrl: baseUrl,
+		apiKey:  apiKey,
+	}
+}
@@ -0,0 +1,38 @@
+package embedding
+
+import (
+	"context"
+	"time"
+)
+
+type ObserveFunc func(err error, latency time.Duration)
+
+// observedProvider reports the outcome and latency of every call, e.g. to adapt
+// consumer concurrency to the provider's health.
+type observedProvider struct {
+	IEmbeddingProvider
+	observe ObserveFunc
+}
+
+func (p *observedProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	start := time.Now()
+	values, err := p.IEmbeddingProvider.Embed(ctx, text, taskType)
+	p.observe(err, time.Since(start))
+
+	return values, err
+}
+
+func (p *observedProvider) EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	start := time.Now()
+	values, err := p.IEmbeddingProvider.EmbedBatch(ctx, texts, taskType)
+	p.observe(err, time.Since(start))
+
+	return values, err
+}
+
+func NewObservedProvider(provider IEmbeddingProvider, observe ObserveFunc) IEmbeddingProvider {
+	return &observedProvider{
+		IEmbeddingProvider: provider,
+		observe:            observe,
+	}
+}
@@ -0,0 +1,108 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type ollamaEmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaEmbeddingResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+type ollamaProvider struct {
+	baseUrl string
+	model   string
+	client  *http.Client
+}
+
+func (p *ollamaProvider) Name() string {
+	return "ollama"
+}
+
+func (p *ollamaProvider) Model() string {
+	return p.model
+}
+
+func (p *ollamaProvider) MaxBatchSize() int {
+	return 256
+}
+
+// Embed uses /api/embeddings, Ollama has no notion of task types.
+func (p *ollamaProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	var res ollamaEmbeddingResponse
+	err := p.post(ctx, "/api/embeddings", ollamaEmbeddingRequest{Model: p.model, Prompt: text}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Embedding, nil
+}
+
+func (p *ollamaProvider) EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	var res ollamaEmbedResponse
+	err := p.post(ctx, "/api/embed", ollamaEmbedRequest{Model: p.model, Input: texts}, &res)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(res.Embeddings), len(texts))
+	}
+
+	return res.Embeddings, nil
+}
+
+func (p *ollamaProvider) post(ctx context.Context, path string, reqBody any, dest any) error {
+	reqJson, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseUrl+path, bytes.NewBuffer(reqJson))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		err = fmt.Errorf("ollama returned status %d: %s", res.StatusCode, body)
+		if res.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("%w: %w", ErrRateLimited, err)
+		}
+		return err
+	}
+
+	return json.NewDecoder(res.Body).Decode(dest)
+}
+
+func NewOllamaProvider(baseUrl string, model string) IEmbeddingProvider {
+	return &ollamaProvider{
+		baseUrl: baseUrl,
+		model:   model,
+		client:  &http.Client{},
+	}
+}
@@ -0,0 +1,24 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	TaskTypeRetrievalDocument = "RETRIEVAL_DOCUMENT"
+	TaskTypeRetrievalQuery    = "RETRIEVAL_QUERY"
+)
+
+// ErrRateLimited is wrapped by provider errors caused by the provider throttling us.
+var ErrRateLimited = errors.New("embedding provider rate limited the request")
+
+type IEmbeddingProvider interface {
+	Name() string
+	Model() string
+	// MaxBatchSize is the most texts a single EmbedBatch call accepts.
+	MaxBatchSize() int
+	Embed(ctx context.Context, text string, taskType string) ([]float32, error)
+	// EmbedBatch embeds all texts in one provider request, results keep the order of texts.
+	EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error)
+}
@@ -0,0 +1,49 @@
+package embedding
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedProvider applies a token bucket in front of a provider, calls wait
+// for a token instead of failing.
+type rateLimitedProvider struct {
+	IEmbeddingProvider
+	limiter *rate.Limiter
+}
+
+func (p *rateLimitedProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	err := p.limiter.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.IEmbeddingProvider.Embed(ctx, text, taskType)
+}
+
+// EmbedBatch takes a single token, providers count a batch as one request.
+func (p *rateLimitedProvider) EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	err := p.limiter.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.IEmbeddingProvider.EmbedBatch(ctx, texts, taskType)
+}
+
+// NewRateLimitedProvider allows requestsPerSecond with bursts up to burst.
+// A non positive requestsPerSecond disables the limit.
+func NewRateLimitedProvider(provider IEmbeddingProvider, requestsPerSecond float64, burst int) IEmbeddingProvider {
+	if requestsPerSecond <= 0 {
+		return provider
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimitedProvider{
+		IEmbeddingProvider: provider,
+		limiter:            rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+	}
+}
@@ -0,0 +1,50 @@
+package embedding
+
+import (
+	"ai-notetaking-be/pkg/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedProvider wraps every provider call in a client span.
+type tracedProvider struct {
+	IEmbeddingProvider
+}
+
+func (p *tracedProvider) Embed(ctx context.Context, text string, taskType string) ([]float32, error) {
+	ctx, span := p.start(ctx, "embedding.Embed", taskType, 1)
+	values, err := p.IEmbeddingProvider.Embed(ctx, text, taskType)
+	tracing.End(span, err)
+
+	return values, err
+}
+
+func (p *tracedProvider) EmbedBatch(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	ctx, span := p.start(ctx, "embedding.EmbedBatch", taskType, len(texts))
+	values, err := p.IEmbeddingProvider.EmbedBatch(ctx, texts, taskType)
+	tracing.End(span, err)
+
+	return values, err
+}
+
+func (p *tracedProvider) start(ctx context.Context, name string, taskType string, documents int) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("embedding.provider", p.Name()),
+			attribute.String("embedding.model", p.Model()),
+			attribute.String("embedding.task_type", taskType),
+			attribute.Int("embedding.documents", documents),
+		),
+	)
+}
+
+func NewTracedProvider(provider IEmbeddingProvider) IEmbeddingProvider {
+	return &tracedProvider{
+		IEmbeddingProvider: provider,
+	}
+}
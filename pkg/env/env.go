@@ -0,0 +1,66 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+func String(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// Reader reads typed variables. A variable that is set but does not parse
+// keeps the fallback and is reported by Err, so a typo never goes unnoticed.
+type Reader struct {
+	errs []error
+}
+
+func (r *Reader) String(key string, fallback string) string {
+	return String(key, fallback)
+}
+
+func (r *Reader) Int(key string, fallback int) int {
+	return parse(r, key, fallback, "an integer", strconv.Atoi)
+}
+
+func (r *Reader) Float(key string, fallback float64) float64 {
+	return parse(r, key, fallback, "a number", func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+func (r *Reader) Bool(key string, fallback bool) bool {
+	return parse(r, key, fallback, "a boolean", strconv.ParseBool)
+}
+
+func (r *Reader) Duration(key string, fallback time.Duration) time.Duration {
+	return parse(r, key, fallback, "a duration", time.ParseDuration)
+}
+
+// Err joins the errors of every variable that did not parse.
+func (r *Reader) Err() error {
+	return errors.Join(r.errs...)
+}
+
+func parse[T any](r *Reader, key string, fallback T, kind string, parseValue func(string) (T, error)) T {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := parseValue(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be %s, got %q", key, kind, value))
+		return fallback
+	}
+
+	return parsed
+}
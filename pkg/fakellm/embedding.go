@@ -0,0 +1,46 @@
+package fakellm
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Embed hashes every word of text into one of dimensions buckets and
+// normalizes the result, so the same text always gets the same vector and
+// texts sharing words end up close to each other under any distance metric.
+func Embed(text string, dimensions int) []float32 {
+	vector := make([]float64, dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(dimensions)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	result := make([]float32, dimensions)
+	// A text without words still needs a unit vector, cosine distance is
+	// undefined for the zero vector.
+	if norm == 0 {
+		result[0] = 1
+		return result
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		result[i] = float32(v / norm)
+	}
+
+	return result
+}
@@ -0,0 +1,235 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Endpoints faults and recorded requests are keyed by.
+const (
+	EndpointEmbedContent       = "embedContent"
+	EndpointBatchEmbedContents = "batchEmbedContents"
+	EndpointEmbeddings         = "embeddings"
+	EndpointEmbed              = "embed"
+	EndpointChat               = "chat"
+	EndpointTags               = "tags"
+)
+
+type Options struct {
+	// Dimensions of the embeddings, 768 by default to fit embedding_notes.
+	Dimensions int
+	// ApiKey, when set, is required in the x-goog-api-key header of Gemini calls.
+	ApiKey string
+	// Models are listed by /api/tags, ollama calls for other models get a 404.
+	// Empty accepts every model and lists none.
+	Models []string
+	// Embed replaces the default bag of words embedding.
+	Embed func(text string) []float32
+	// Reply answers a chat, by default with a fixed sentence.
+	Reply func(messages []ChatMessage) string
+	// Latency is added to every request, TokenDelay between streamed chunks.
+	Latency    time.Duration
+	TokenDelay time.Duration
+	// ErrorRate is the share of requests randomly failed with ErrorStatus,
+	// 429 by default.
+	ErrorRate   float64
+	ErrorStatus int
+}
+
+// Fault fails the requests to Endpoint, every endpoint when empty, with
+// Status after Delay. Count limits it to that many requests, 0 keeps it until
+// the faults are cleared. A Status of 0 only delays.
+type Fault struct {
+	Endpoint string
+	Status   int
+	Delay    time.Duration
+	Count    int
+}
+
+// Server is a stand-in for the Gemini embedding API and the ollama endpoints
+// the app calls. Embeddings are deterministic, texts sharing words are close.
+type Server struct {
+	options Options
+	mux     *http.ServeMux
+
+	mu       sync.Mutex
+	faults   []*Fault
+	requests map[string][][]byte
+}
+
+func New(options Options) *Server {
+	if options.Dimensions <= 0 {
+		options.Dimensions = 768
+	}
+	if options.ErrorStatus == 0 {
+		options.ErrorStatus = http.StatusTooManyRequests
+	}
+	if options.Embed == nil {
+		dimensions := options.Dimensions
+		options.Embed = func(text string) []float32 {
+			return Embed(text, dimensions)
+		}
+	}
+	if options.Reply == nil {
+		options.Reply = DefaultReply
+	}
+
+	s := &Server{
+		options:  options,
+		mux:      http.NewServeMux(),
+		requests: make(map[string][][]byte),
+	}
+	s.mux.HandleFunc("POST /v1beta/models/{call}", s.handleGemini)
+	s.mux.HandleFunc("POST /api/embeddings", s.handle(EndpointEmbeddings, s.handleOllamaEmbeddings))
+	s.mux.HandleFunc("POST /api/embed", s.handle(EndpointEmbed, s.handleOllamaEmbed))
+	s.mux.HandleFunc("POST /api/chat", s.handle(EndpointChat, s.handleOllamaChat))
+	s.mux.HandleFunc("GET /api/tags", s.handle(EndpointTags, s.handleOllamaTags))
+	s.mux.HandleFunc("POST /fakellm/faults", s.handleAddFault)
+	s.mux.HandleFunc("DELETE /fakellm/faults", s.handleClearFaults)
+
+	return s
+}
+
+// Start serves s on a local port until the returned server is closed.
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) AddFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the bodies of the requests to endpoint, in arrival order.
+func (s *Server) Requests(endpoint string) [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests[endpoint])
+}
+
+type errorWriter func(w http.ResponseWriter, status int, message string)
+
+// handle records the request and applies latency and faults before next.
+func (s *Server) handle(endpoint string, next func(w http.ResponseWriter, body []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.serve(w, r, endpoint, writeOllamaError, next)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, endpoint string, writeError errorWriter, next func(w http.ResponseWriter, body []byte)) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fault := s.record(endpoint, body)
+	delay := s.options.Latency
+	if fault != nil {
+		delay += fault.Delay
+	}
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	if fault != nil && fault.Status != 0 {
+		writeError(w, fault.Status, "injected fault")
+		return
+	}
+	if s.options.ErrorRate > 0 && rand.Float64() < s.options.ErrorRate {
+		writeError(w, s.options.ErrorStatus, "injected random failure")
+		return
+	}
+
+	next(w, body)
+}
+
+// record keeps body and consumes the first fault matching endpoint.
+func (s *Server) record(endpoint string, body []byte) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[endpoint] = append(s.requests[endpoint], body)
+	for i, fault := range s.faults {
+		if fault.Endpoint != "" && fault.Endpoint != endpoint {
+			continue
+		}
+		matched := *fault
+		if fault.Count > 0 {
+			fault.Count--
+			if fault.Count == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+		return &matched
+	}
+
+	return nil
+}
+
+type faultRequest struct {
+	Endpoint string `json:"endpoint"`
+	Status   int    `json:"status"`
+	Delay    string `json:"delay"`
+	Count    int    `json:"count"`
+}
+
+// handleAddFault lets a dev server be broken from the outside, e.g.
+// curl -X POST localhost:11435/fakellm/faults -d '{"endpoint":"chat","status":503,"count":3}'
+func (s *Server) handleAddFault(w http.ResponseWriter, r *http.Request) {
+	var request faultRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var delay time.Duration
+	if request.Delay != "" {
+		delay, err = time.ParseDuration(request.Delay)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.AddFault(Fault{
+		Endpoint: request.Endpoint,
+		Status:   request.Status,
+		Delay:    delay,
+		Count:    request.Count,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleClearFaults(w http.ResponseWriter, r *http.Request) {
+	s.ClearFaults()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
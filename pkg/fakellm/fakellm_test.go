@@ -0,0 +1,164 @@
+package fakellm_test
+
+import (
+	"ai-notetaking-be/pkg/embedding"
+	"ai-notetaking-be/pkg/fakellm"
+	"ai-notetaking-be/pkg/gemini"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func start(t *testing.T, options fakellm.Options) (*fakellm.Server, string) {
+	t.Helper()
+
+	server := fakellm.New(options)
+	httpServer := server.Start()
+	t.Cleanup(httpServer.Close)
+
+	return server, httpServer.URL
+}
+
+func TestGeminiProvider(t *testing.T) {
+	_, url := start(t, fakellm.Options{ApiKey: "secret"})
+	provider := embedding.NewGeminiProvider(url, "secret")
+	ctx := context.Background()
+
+	single, err := provider.Embed(ctx, "Groceries for the week", embedding.TaskTypeRetrievalDocument)
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(single) != 768 {
+		t.Fatalf("embedding has %d dimensions", len(single))
+	}
+
+	batch, err := provider.EmbedBatch(ctx, []string{"Groceries for the week", "Meeting notes"}, embedding.TaskTypeRetrievalDocument)
+	if err != nil {
+		t.Fatalf("embed batch: %v", err)
+	}
+	if len(batch) != 2 || !slices.Equal(batch[0], single) {
+		t.Fatal("batch embeddings differ from single embeddings of the same text")
+	}
+}
+
+func TestGeminiProviderRejectsWrongApiKey(t *testing.T) {
+	_, url := start(t, fakellm.Options{ApiKey: "secret"})
+
+	_, err := embedding.NewGeminiProvider(url, "wrong").Embed(context.Background(), "text", embedding.TaskTypeRetrievalQuery)
+
+	var embedErr *gemini.EmbedError
+	if !errors.As(err, &embedErr) || embedErr.Type != gemini.ErrTypeInvalidAPIKey {
+		t.Fatalf("error = %v, want an invalid api key error", err)
+	}
+}
+
+func TestFaultsAreInjectedPerEndpoint(t *testing.T) {
+	server, url := start(t, fakellm.Options{})
+	server.AddFault(fakellm.Fault{Endpoint: fakellm.EndpointBatchEmbedContents, Status: http.StatusTooManyRequests, Count: 1})
+	provider := embedding.NewGeminiProvider(url, "key")
+	ctx := context.Background()
+
+	_, err := provider.Embed(ctx, "text", embedding.TaskTypeRetrievalQuery)
+	if err != nil {
+		t.Fatalf("embed hit a fault of another endpoint: %v", err)
+	}
+	_, err = provider.EmbedBatch(ctx, []string{"text"}, embedding.TaskTypeRetrievalDocument)
+	if !errors.Is(err, embedding.ErrRateLimited) {
+		t.Fatalf("error = %v, want rate limited", err)
+	}
+	_, err = provider.EmbedBatch(ctx, []string{"text"}, embedding.TaskTypeRetrievalDocument)
+	if err != nil {
+		t.Fatalf("fault outlived its count: %v", err)
+	}
+	if n := len(server.Requests(fakellm.EndpointBatchEmbedContents)); n != 2 {
+		t.Fatalf("recorded %d batch requests, want 2", n)
+	}
+}
+
+func TestOllamaProvider(t *testing.T) {
+	_, url := start(t, fakellm.Options{Models: []string{"nomic-embed-text:v1.5"}})
+	ctx := context.Background()
+
+	texts := []string{"a", "b", "c"}
+	embeddings, err := embedding.NewOllamaProvider(url, "nomic-embed-text:v1.5").EmbedBatch(ctx, texts, embedding.TaskTypeRetrievalDocument)
+	if err != nil {
+		t.Fatalf("embed batch: %v", err)
+	}
+	if len(embeddings) != len(texts) {
+		t.Fatalf("got %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+
+	_, err = embedding.NewOllamaProvider(url, "missing").Embed(ctx, "a", embedding.TaskTypeRetrievalQuery)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("error = %v, want a 404 for a model that is not pulled", err)
+	}
+}
+
+func TestEmbeddingsOfRelatedTextsAreCloser(t *testing.T) {
+	query := fakellm.Embed("chocolate cake recipe", 768)
+	related := fakellm.Embed("A recipe for chocolate cake with cherries", 768)
+	unrelated := fakellm.Embed("Quarterly budget review meeting", 768)
+
+	if dot(query, related) <= dot(query, unrelated) {
+		t.Fatal("related text is not closer than unrelated text")
+	}
+}
+
+func TestChatStreams(t *testing.T) {
+	_, url := start(t, fakellm.Options{Reply: func(messages []fakellm.ChatMessage) string {
+		return "one two three"
+	}})
+
+	body, _ := json.Marshal(map[string]any{
+		"model":    "llama3.2",
+		"messages": []fakellm.ChatMessage{{Role: "user", Content: "count"}},
+	})
+	res, err := http.Post(url+"/api/chat", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	defer res.Body.Close()
+
+	var content strings.Builder
+	chunks := 0
+	done := false
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var chunk struct {
+			Message   fakellm.ChatMessage `json:"message"`
+			Done      bool                `json:"done"`
+			EvalCount int                 `json:"eval_count"`
+		}
+		err = json.Unmarshal(scanner.Bytes(), &chunk)
+		if err != nil {
+			t.Fatalf("decode chunk: %v", err)
+		}
+		chunks++
+		content.WriteString(chunk.Message.Content)
+		if chunk.Done {
+			done = true
+			if chunk.EvalCount != 3 {
+				t.Fatalf("eval_count = %d", chunk.EvalCount)
+			}
+		}
+	}
+
+	if content.String() != "one two three" || chunks != 4 || !done {
+		t.Fatalf("streamed %q in %d chunks, done %v", content.String(), chunks, done)
+	}
+}
+
+func dot(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+
+	return sum
+}
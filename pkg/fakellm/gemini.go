@@ -0,0 +1,121 @@
+package fakellm
+
+import (
+	"ai-notetaking-be/pkg/gemini"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type geminiErrorResponse struct {
+	Error geminiError `json:"error"`
+}
+
+type geminiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// handleGemini serves models/{model}:embedContent and :batchEmbedContents,
+// one path segment the mux can not split.
+func (s *Server) handleGemini(w http.ResponseWriter, r *http.Request) {
+	_, method, _ := strings.Cut(r.PathValue("call"), ":")
+	switch method {
+	case EndpointEmbedContent:
+		s.serve(w, r, method, writeGeminiError, s.authorized(r, s.handleEmbedContent))
+	case EndpointBatchEmbedContents:
+		s.serve(w, r, method, writeGeminiError, s.authorized(r, s.handleBatchEmbedContents))
+	default:
+		writeGeminiError(w, http.StatusNotFound, fmt.Sprintf("method %q is not supported", method))
+	}
+}
+
+func (s *Server) authorized(r *http.Request, next func(w http.ResponseWriter, body []byte)) func(w http.ResponseWriter, body []byte) {
+	return func(w http.ResponseWriter, body []byte) {
+		if s.options.ApiKey != "" && r.Header.Get("x-goog-api-key") != s.options.ApiKey {
+			writeGeminiError(w, http.StatusForbidden, "API key not valid. Please pass a valid API key.")
+			return
+		}
+
+		next(w, body)
+	}
+}
+
+func (s *Server) handleEmbedContent(w http.ResponseWriter, body []byte) {
+	var request gemini.EmbedContentRequest
+	err := json.Unmarshal(body, &request)
+	if err != nil {
+		writeGeminiError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJson(w, http.StatusOK, gemini.EmbedContentResponse{
+		Embedding: gemini.Embedding{Values: s.options.Embed(contentText(request.Content))},
+	})
+}
+
+func (s *Server) handleBatchEmbedContents(w http.ResponseWriter, body []byte) {
+	var request gemini.BatchEmbedContentsRequest
+	err := json.Unmarshal(body, &request)
+	if err != nil {
+		writeGeminiError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(request.Requests) > gemini.MaxBatchSize {
+		writeGeminiError(w, http.StatusBadRequest, fmt.Sprintf("at most %d requests can be in one batch", gemini.MaxBatchSize))
+		return
+	}
+
+	response := gemini.BatchEmbedContentsResponse{
+		Embeddings: make([]gemini.Embedding, 0, len(request.Requests)),
+	}
+	for _, r := range request.Requests {
+		response.Embeddings = append(response.Embeddings, gemini.Embedding{
+			Values: s.options.Embed(contentText(r.Content)),
+		})
+	}
+	writeJson(w, http.StatusOK, response)
+}
+
+func contentText(content gemini.Content) string {
+	texts := make([]string, 0, len(content.Parts))
+	for _, part := range content.Parts {
+		texts = append(texts, part.Text)
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+func writeGeminiError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, geminiErrorResponse{
+		Error: geminiError{
+			Code:    status,
+			Message: message,
+			Status:  geminiStatus(status),
+		},
+	})
+}
+
+// geminiStatus is the google.rpc code Gemini reports next to the http status.
+func geminiStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	}
+
+	return "INTERNAL"
+}
@@ -0,0 +1,201 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaEmbeddingsRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaEmbeddingsResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+type ollamaEmbedRequest struct {
+	Model string          `json:"model"`
+	Input json.RawMessage `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	// Stream is true when left out, like ollama does.
+	Stream *bool `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Model              string      `json:"model"`
+	CreatedAt          string      `json:"created_at"`
+	Message            ChatMessage `json:"message"`
+	DoneReason         string      `json:"done_reason,omitempty"`
+	Done               bool        `json:"done"`
+	TotalDuration      int64       `json:"total_duration,omitempty"`
+	LoadDuration       int64       `json:"load_duration,omitempty"`
+	PromptEvalCount    int         `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64       `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int         `json:"eval_count,omitempty"`
+	EvalDuration       int64       `json:"eval_duration,omitempty"`
+}
+
+type ollamaTagsResponse struct {
+	Models []ollamaModel `json:"models"`
+}
+
+type ollamaModel struct {
+	Name  string `json:"name"`
+	Model string `json:"model"`
+}
+
+type ollamaErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func DefaultReply(messages []ChatMessage) string {
+	return "This is a canned answer from fakellm, no model was asked."
+}
+
+func (s *Server) handleOllamaEmbeddings(w http.ResponseWriter, body []byte) {
+	var request ollamaEmbeddingsRequest
+	if !s.decodeOllama(w, body, &request) || !s.checkModel(w, request.Model) {
+		return
+	}
+
+	writeJson(w, http.StatusOK, ollamaEmbeddingsResponse{
+		Embedding: s.options.Embed(request.Prompt),
+	})
+}
+
+func (s *Server) handleOllamaEmbed(w http.ResponseWriter, body []byte) {
+	var request ollamaEmbedRequest
+	if !s.decodeOllama(w, body, &request) || !s.checkModel(w, request.Model) {
+		return
+	}
+	// input is a single string or a list of them.
+	var inputs []string
+	var input string
+	if json.Unmarshal(request.Input, &input) == nil {
+		inputs = []string{input}
+	} else if err := json.Unmarshal(request.Input, &inputs); err != nil {
+		writeOllamaError(w, http.StatusBadRequest, "input must be a string or a list of strings")
+		return
+	}
+
+	response := ollamaEmbedResponse{
+		Model:      request.Model,
+		Embeddings: make([][]float32, 0, len(inputs)),
+	}
+	for _, text := range inputs {
+		response.Embeddings = append(response.Embeddings, s.options.Embed(text))
+	}
+	writeJson(w, http.StatusOK, response)
+}
+
+func (s *Server) handleOllamaChat(w http.ResponseWriter, body []byte) {
+	var request ollamaChatRequest
+	if !s.decodeOllama(w, body, &request) || !s.checkModel(w, request.Model) {
+		return
+	}
+
+	start := time.Now()
+	reply := s.options.Reply(request.Messages)
+	promptTokens := 0
+	for _, message := range request.Messages {
+		promptTokens += len(strings.Fields(message.Content))
+	}
+	done := ollamaChatResponse{
+		Model:           request.Model,
+		Message:         ChatMessage{Role: "assistant"},
+		DoneReason:      "stop",
+		Done:            true,
+		PromptEvalCount: promptTokens,
+		EvalCount:       len(strings.Fields(reply)),
+	}
+
+	if request.Stream != nil && !*request.Stream {
+		done.Message.Content = reply
+		done.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+		done.TotalDuration = time.Since(start).Nanoseconds()
+		writeJson(w, http.StatusOK, done)
+		return
+	}
+
+	// Streams a chunk per word as newline delimited json, then the stats.
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for _, token := range strings.SplitAfter(reply, " ") {
+		time.Sleep(s.options.TokenDelay)
+		encoder.Encode(ollamaChatResponse{
+			Model:     request.Model,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			Message:   ChatMessage{Role: "assistant", Content: token},
+		})
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	done.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	done.TotalDuration = time.Since(start).Nanoseconds()
+	encoder.Encode(done)
+}
+
+func (s *Server) handleOllamaTags(w http.ResponseWriter, body []byte) {
+	response := ollamaTagsResponse{
+		Models: make([]ollamaModel, 0, len(s.options.Models)),
+	}
+	for _, model := range s.options.Models {
+		response.Models = append(response.Models, ollamaModel{Name: model, Model: model})
+	}
+	writeJson(w, http.StatusOK, response)
+}
+
+// decodeOllama and checkModel write the error response when they return false.
+func (s *Server) decodeOllama(w http.ResponseWriter, body []byte, request any) bool {
+	err := json.Unmarshal(body, request)
+	if err != nil {
+		writeOllamaError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) checkModel(w http.ResponseWriter, model string) bool {
+	if !s.hasModel(model) {
+		writeOllamaError(w, http.StatusNotFound, fmt.Sprintf("model %q not found, try pulling it first", model))
+		return false
+	}
+
+	return true
+}
+
+// hasModel accepts untagged names for models listed under :latest.
+func (s *Server) hasModel(model string) bool {
+	if len(s.options.Models) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.options.Models, model) || slices.Contains(s.options.Models, model+":latest") ||
+		slices.Contains(s.options.Models, strings.TrimSuffix(model, ":latest"))
+}
+
+func writeOllamaError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, ollamaErrorResponse{Error: message})
+}
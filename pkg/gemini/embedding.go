@@ -2,10 +2,12 @@ package gemini
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 type EmbedContentRequest struct {
@@ -30,11 +32,70 @@ type Embedding struct {
 	Values []float32 `json:"values"`
 }
 
-func GetEmbedding(apiKey string, text string, taskType string) (*EmbedContentResponse, error) {
-	url := "https://generativelanguage.googleapis.com/v1beta/models/gemini-embedding-exp-03-07:embedContent"
+type BatchEmbedContentsRequest struct {
+	Requests []EmbedContentRequest `json:"requests"`
+}
+
+type BatchEmbedContentsResponse struct {
+	Embeddings []Embedding `json:"embeddings"`
+}
+
+const EmbeddingModel = "gemini-embedding-exp-03-07"
+
+// DefaultBaseUrl is the public Gemini API, tests and offline setups point the
+// client at a stand-in such as pkg/fakellm instead.
+const DefaultBaseUrl = "https://generativelanguage.googleapis.com"
+
+// MaxBatchSize is the most requests batchEmbedContents accepts at once.
+const MaxBatchSize = 100
+
+// client is shared so connections are reused. Its timeout bounds a call whose
+// ctx has no deadline, e.g. a hung connection in a consumer.
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func GetEmbedding(ctx context.Context, baseUrl string, apiKey string, text string, taskType string) (*EmbedContentResponse, error) {
+	url := fmt.Sprintf("%s/v1beta/models/%s:embedContent", baseUrl, EmbeddingModel)
+
+	reqBody := newEmbedContentRequest(text, taskType)
+
+	var result EmbedContentResponse
+	err := post(ctx, apiKey, url, reqBody, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func GetBatchEmbedding(ctx context.Context, baseUrl string, apiKey string, texts []string, taskType string) (*BatchEmbedContentsResponse, error) {
+	url := fmt.Sprintf("%s/v1beta/models/%s:batchEmbedContents", baseUrl, EmbeddingModel)
 
-	reqBody := EmbedContentRequest{
-		Model: "models/gemini-embedding-exp-03-07",
+	reqBody := BatchEmbedContentsRequest{
+		Requests: make([]EmbedContentRequest, 0, len(texts)),
+	}
+	for _, text := range texts {
+		reqBody.Requests = append(reqBody.Requests, newEmbedContentRequest(text, taskType))
+	}
+
+	var result BatchEmbedContentsResponse
+	err := post(ctx, apiKey, url, reqBody, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, &EmbedError{
+			Type:    ErrTypeJSONUnmarshal,
+			Message: "Unexpected number of embeddings",
+			Err:     fmt.Errorf("requested %d, received %d", len(texts), len(result.Embeddings)),
+		}
+	}
+
+	return &result, nil
+}
+
+func newEmbedContentRequest(text string, taskType string) EmbedContentRequest {
+	return EmbedContentRequest{
+		Model: "models/" + EmbeddingModel,
 		Content: Content{
 			Parts: []Part{
 				{Text: text},
@@ -42,30 +103,31 @@ func GetEmbedding(apiKey string, text string, taskType string) (*EmbedContentRes
 		},
 		TaskType: taskType,
 	}
+}
 
+func post(ctx context.Context, apiKey string, url string, reqBody any, dest any) error {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, &EmbedError{Type: ErrTypeMarshalRequest, Message: "Failed to marshal request", Err: err}
+		return &EmbedError{Type: ErrTypeMarshalRequest, Message: "Failed to marshal request", Err: err}
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, &EmbedError{Type: ErrTypeRequestFailed, Message: "Failed to create request", Err: err}
+		return &EmbedError{Type: ErrTypeRequestFailed, Message: "Failed to create request", Err: err}
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-goog-api-key", apiKey)
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, &EmbedError{Type: ErrTypeRequestFailed, Message: "Request failed", Err: err}
+		return &EmbedError{Type: ErrTypeRequestFailed, Message: "Request failed", Err: err}
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &EmbedError{Type: ErrTypeRequestFailed, Message: "Failed to read response", Err: err}
+		return &EmbedError{Type: ErrTypeRequestFailed, Message: "Failed to read response", Err: err}
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -73,17 +135,19 @@ func GetEmbedding(apiKey string, text string, taskType string) (*EmbedContentRes
 		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
 			errorType = ErrTypeInvalidAPIKey
 		}
-		return nil, &EmbedError{
+		if resp.StatusCode == http.StatusTooManyRequests {
+			errorType = ErrTypeRateLimited
+		}
+		return &EmbedError{
 			Type:    errorType,
 			Message: fmt.Sprintf("API returned status %d", resp.StatusCode),
 			Err:     fmt.Errorf("response: %s", string(body)),
 		}
 	}
 
-	var result EmbedContentResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, &EmbedError{Type: ErrTypeJSONUnmarshal, Message: "Failed to unmarshal response", Err: err}
+	if err := json.Unmarshal(body, dest); err != nil {
+		return &EmbedError{Type: ErrTypeJSONUnmarshal, Message: "Failed to unmarshal response", Err: err}
 	}
 
-	return &result, nil
+	return nil
 }
@@ -11,8 +11,28 @@ const (
 	ErrTypeRequestFailed
 	ErrTypeJSONUnmarshal
 	ErrTypeMarshalRequest
+	ErrTypeRateLimited
 )
 
+func (t EmbedErrorType) String() string {
+	switch t {
+	case ErrTypeInvalidAPIKey:
+		return "invalid_api_key"
+	case ErrTypeHTTPStatus:
+		return "http_status"
+	case ErrTypeRequestFailed:
+		return "request_failed"
+	case ErrTypeJSONUnmarshal:
+		return "json_unmarshal"
+	case ErrTypeMarshalRequest:
+		return "marshal_request"
+	case ErrTypeRateLimited:
+		return "rate_limited"
+	}
+
+	return "unknown"
+}
+
 type EmbedError struct {
 	Type    EmbedErrorType
 	Message string
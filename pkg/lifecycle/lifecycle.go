@@ -0,0 +1,88 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type ShutdownFunc func(ctx context.Context) error
+
+type shutdownHook struct {
+	name string
+	fn   ShutdownFunc
+}
+
+// Manager owns the application context and tears components down in the order
+// they were registered once SIGINT/SIGTERM arrives or Stop is called.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	shutdownTimeout time.Duration
+
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+// Context is cancelled as soon as shutdown starts. Components use it to stop
+// accepting new work, not to abort work in flight.
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+func (m *Manager) OnShutdown(name string, fn ShutdownFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// Stop starts the shutdown without a signal, e.g. when the HTTP listener dies.
+func (m *Manager) Stop() {
+	m.cancel()
+}
+
+// Wait blocks until shutdown starts, then runs every hook under a shared deadline
+// and reports what could not be finished cleanly.
+func (m *Manager) Wait() error {
+	<-m.ctx.Done()
+	slog.Info("Shutting down", "timeout", m.shutdownTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	m.mu.Lock()
+	hooks := append([]shutdownHook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, hook := range hooks {
+		start := time.Now()
+		err := hook.fn(ctx)
+		if err != nil {
+			slog.Error("Shutdown failed", "component", hook.name, "duration", time.Since(start), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			continue
+		}
+		slog.Info("Shutdown finished", "component", hook.name, "duration", time.Since(start))
+	}
+
+	return errors.Join(errs...)
+}
+
+func NewManager(shutdownTimeout time.Duration) *Manager {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	return &Manager{
+		ctx:             ctx,
+		cancel:          cancel,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
@@ -0,0 +1,311 @@
+package migration
+
+import (
+	"ai-notetaking-be/pkg/database"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey is the advisory lock held while migrating, replicas starting at the
+// same time wait for each other instead of applying a migration twice.
+const lockKey = "ai-notetaking-be:schema_migrations"
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// NoTransaction opens a migration file that can not run in a transaction, such
+// as CREATE INDEX CONCURRENTLY. The file has to hold a single statement, and
+// a failure leaves the version dirty for the schema to be repaired by hand.
+const NoTransaction = "-- migrate:no-transaction"
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version uint64
+	Name    string
+	Applied bool
+}
+
+type Status struct {
+	// Version is the last applied migration, 0 when none is.
+	Version    uint64
+	Dirty      bool
+	Migrations []MigrationStatus
+}
+
+// Migrator applies the <version>_<name>.up.sql/.down.sql files of a directory.
+// It keeps its state in the schema_migrations table of golang-migrate, so
+// databases migrated with the migrate CLI carry on where they are.
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(db *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies every migration newer than the current version and returns how
+// many it applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			err = apply(ctx, conn, migration.Up, &migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last steps migrations and returns how many it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		for ; reverted < steps; reverted++ {
+			version, err := currentVersion(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if version == 0 {
+				return nil
+			}
+
+			i := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+				return migration.Version == version
+			})
+			if i == -1 {
+				return fmt.Errorf("no migration found for the current version %d", version)
+			}
+			migration := m.migrations[i]
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			var previous *uint64
+			if i > 0 {
+				previous = &m.migrations[i-1].Version
+			}
+			err = apply(ctx, conn, migration.Down, previous)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Reverted migration", "version", migration.Version, "name", migration.Name)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	status := &Status{}
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		row := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+		var version int64
+		err := row.Scan(&version, &status.Dirty)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if version > 0 {
+			status.Version = uint64(version)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= status.Version,
+		})
+	}
+
+	return status, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, the
+// lock is tied to the session so the connection can not go back to the pool
+// in between.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1))", lockKey)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(hashtext($1))", lockKey)
+
+	_, err = conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func currentVersion(ctx context.Context, conn *pgxpool.Conn) (uint64, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	// Only the migrate CLI and failed NoTransaction migrations leave a dirty
+	// version behind.
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d, repair the schema and reset the dirty flag first", version)
+	}
+	if version < 0 {
+		return 0, nil
+	}
+
+	return uint64(version), nil
+}
+
+// apply runs sql and records version, nil for none, in one transaction, so a
+// failing migration leaves neither schema changes nor a dirty version behind.
+// NoTransaction migrations are the exception.
+func apply(ctx context.Context, conn *pgxpool.Conn, sql string, version *uint64) error {
+	if strings.HasPrefix(strings.TrimSpace(sql), NoTransaction) {
+		return applyWithoutTransaction(ctx, conn, sql, version)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, sql)
+	if err != nil {
+		return err
+	}
+	err = setVersion(ctx, tx, version, false)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// applyWithoutTransaction marks version dirty while sql runs, the way the
+// migrate CLI does, so a failure half way is noticed on the next run.
+func applyWithoutTransaction(ctx context.Context, conn *pgxpool.Conn, sql string, version *uint64) error {
+	err := setVersion(ctx, conn, version, true)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, sql)
+	if err != nil {
+		return err
+	}
+
+	return setVersion(ctx, conn, version, false)
+}
+
+// setVersion records version, nil for none, as the only row of
+// schema_migrations. A dirty state without a version is recorded as -1.
+func setVersion(ctx context.Context, db database.DatabaseQueryer, version *uint64, dirty bool) error {
+	_, err := db.Exec(ctx, "DELETE FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version != nil:
+		_, err = db.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", int64(*version), dirty)
+	case dirty:
+		_, err = db.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES (-1, true)")
+	}
+
+	return err
+}
@@ -0,0 +1,212 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	ErrNotConnected = errors.New("rabbitmq: not connected")
+	ErrClosed       = errors.New("rabbitmq: connection closed")
+)
+
+// SetupFunc prepares a fresh channel, e.g. declares queues, and runs again after
+// every reconnect.
+type SetupFunc func(ch *amqp.Channel) error
+
+// Connection keeps one connection and channel alive, reconnecting with
+// exponential backoff whenever either of them is closed.
+type Connection struct {
+	url   string
+	setup SetupFunc
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu      sync.RWMutex
+	conn    *amqp.Connection
+	ch      *amqp.Channel
+	ready   chan struct{}
+	closing chan struct{}
+	closed  bool
+}
+
+// Channel returns the current channel, or ErrNotConnected while reconnecting.
+func (c *Connection) Channel() (*amqp.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if c.ch == nil {
+		return nil, ErrNotConnected
+	}
+
+	return c.ch, nil
+}
+
+// WaitReady blocks until a channel is available or ctx is done.
+func (c *Connection) WaitReady(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		c.mu.RLock()
+		ch, ready, closed := c.ch, c.ready, c.closed
+		c.mu.RUnlock()
+		if closed {
+			return nil, ErrClosed
+		}
+		if ch != nil {
+			return ch, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrNotConnected
+		case <-ready:
+		}
+	}
+}
+
+func (c *Connection) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.closing)
+	conn := c.conn
+	c.conn = nil
+	c.ch = nil
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	return conn.Close()
+}
+
+func (c *Connection) supervise() {
+	backoff := c.minBackoff
+	for {
+		conn, ch, err := c.connect()
+		if err != nil {
+			slog.Warn("RabbitMQ connection failed", "retry_in", backoff, "error", err)
+			select {
+			case <-c.closing:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, c.maxBackoff)
+			continue
+		}
+		backoff = c.minBackoff
+
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.conn = conn
+		c.ch = ch
+		close(c.ready)
+		c.mu.Unlock()
+		slog.Info("RabbitMQ connected")
+
+		var reason *amqp.Error
+		select {
+		case <-c.closing:
+			return
+		case reason = <-connClosed:
+		case reason = <-chClosed:
+		}
+		slog.Warn("RabbitMQ connection lost, reconnecting", "reason", reason)
+
+		c.mu.Lock()
+		c.conn = nil
+		c.ch = nil
+		c.ready = make(chan struct{})
+		c.mu.Unlock()
+		conn.Close()
+	}
+}
+
+func (c *Connection) connect() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if c.setup != nil {
+		err = c.setup(ch)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+
+	return conn, ch, nil
+}
+
+// NewConnection returns immediately and connects in the background, so a broker
+// that is down at startup does not take the service down with it.
+func NewConnection(url string, setup SetupFunc) *Connection {
+	c := &Connection{
+		url:        url,
+		setup:      setup,
+		minBackoff: time.Second,
+		maxBackoff: 30 * time.Second,
+		ready:      make(chan struct{}),
+		closing:    make(chan struct{}),
+	}
+	go c.supervise()
+
+	return c
+}
+
+// DeclareQueue is the SetupFunc declaring the durable queue used by publishers
+// and consumers.
+func DeclareQueue(queueName string) SetupFunc {
+	return func(ch *amqp.Channel) error {
+		_, err := declareQueue(ch, queueName)
+		return err
+	}
+}
+
+// InspectQueue returns the ready message and consumer counts of the queue.
+// Declaring an existing queue with the same arguments leaves it untouched.
+func (c *Connection) InspectQueue(queueName string) (amqp.Queue, error) {
+	ch, err := c.Channel()
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+
+	return declareQueue(ch, queueName)
+}
+
+func declareQueue(ch *amqp.Channel, queueName string) (amqp.Queue, error) {
+	return ch.QueueDeclare(
+		queueName,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+}
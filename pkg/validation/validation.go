@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"ai-notetaking-be/pkg/apperror"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+// Struct checks the `validate` tags of a request DTO. Failures come back as an
+// apperror validation error with one entry per field, named like the client
+// sent it.
+func Struct(v any) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]apperror.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, apperror.FieldError{
+			Field:   fieldErr.Field(),
+			Message: message(fieldErr),
+		})
+	}
+
+	return apperror.InvalidFields(fields...)
+}
+
+func message(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "max":
+		switch {
+		case fieldErr.Kind() == reflect.String:
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		case isNumber(fieldErr.Kind()):
+			return fmt.Sprintf("must be at most %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("must have at most %s items", fieldErr.Param())
+	case "min":
+		switch {
+		case fieldErr.Kind() == reflect.String:
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		case isNumber(fieldErr.Kind()):
+			return fmt.Sprintf("must be at least %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("must have at least %s items", fieldErr.Param())
+	case "url", "http_url":
+		return "must be a valid http(s) url"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fieldErr.Param()), ", "))
+	}
+
+	return fmt.Sprintf("failed the %s check", fieldErr.Tag())
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their json or query name rather than the Go one.
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	return v
+}